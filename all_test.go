@@ -1,8 +1,24 @@
-package main
+package movinfo
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestParse(t *testing.T) {
@@ -15,6 +31,7 @@ func TestParse(t *testing.T) {
 			want: result{
 				start:      "00:00:00:00",
 				end:        "00:00:04:05",
+				base:       24,
 				duration:   "102",
 				resolution: "1920*1080",
 			},
@@ -24,10 +41,46 @@ func TestParse(t *testing.T) {
 			want: result{
 				start:      "20:51:01:20",
 				end:        "20:51:05:07",
+				base:       24,
 				duration:   "84",
 				resolution: "1920*1080",
 			},
 		},
+		{
+			// nb_frames counts fields.
+			file: "testdata/ffprobe_4.out",
+			want: result{
+				start:      "10:00:00;00",
+				end:        "10:00:03;14",
+				base:       30,
+				duration:   "105",
+				resolution: "1920*1080",
+				warnings:   []string{"nb_frames 210 seems to count fields of interlaced video, corrected to 105"},
+			},
+		},
+		{
+			// numbers have decimal comma.
+			file: "testdata/ffprobe_12.out",
+			want: result{
+				start:      "10:00:00;00",
+				end:        "10:00:03;14",
+				base:       30,
+				duration:   "105",
+				resolution: "1920*1080",
+				warnings:   []string{"nb_frames 210 seems to count fields of interlaced video, corrected to 105"},
+			},
+		},
+		{
+			// tmcd track runs at half rate of the video.
+			file: "testdata/ffprobe_3.out",
+			want: result{
+				start:      "01:00:00;00",
+				end:        "01:00:03;29",
+				base:       30,
+				duration:   "240",
+				resolution: "1920*1080",
+			},
+		},
 	}
 	cfg := config{
 		start:      true,
@@ -45,8 +98,3842 @@ func TestParse(t *testing.T) {
 		if err != nil {
 			t.Fatalf("parse error: %v", err)
 		}
-		if got != c.want {
+		if !reflect.DeepEqual(got, c.want) {
 			t.Fatalf("got %v, want %v", got, c.want)
 		}
 	}
 }
+
+func TestParseTimecodeRate(t *testing.T) {
+	cases := []struct {
+		file  string
+		check string
+	}{
+		{"testdata/ffprobe_1.out", "ok"},
+		// tmcd track of half the rate is fine.
+		{"testdata/ffprobe_3.out", "ok"},
+		{"testdata/ffprobe_47.out", "timecode track is 30 fps, but the video is 23.98 fps"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{checkTimecodeRate: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.timecodeRateCheck != c.check {
+			t.Fatalf("%v: got %v, want %v", c.file, got.timecodeRateCheck, c.check)
+		}
+	}
+	b, err := os.ReadFile("testdata/ffprobe_47.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_47.out")
+	}
+	ends := []struct {
+		endRate string
+		end     string
+		warning string
+	}{
+		// 102 frames of 23.976 last 127.5 frames of 30 fps.
+		{endRateTimecode, "01:00:04:07", "timecode track is 30 fps, but the video is 23.98 fps, end is counted in the rate of the timecode (-end-rate)"},
+		{endRateVideo, "01:00:04:05", "timecode track is 30 fps, but the video is 23.98 fps, end is counted in the rate of the video (-end-rate)"},
+	}
+	for _, c := range ends {
+		got, err := parse(string(b), config{end: true, endRate: c.endRate})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.endRate, err)
+		}
+		if got.end != c.end || !reflect.DeepEqual(got.warnings, []string{c.warning}) {
+			t.Fatalf("%v: got %v %q, want %v %q", c.endRate, got.end, got.warnings, c.end, c.warning)
+		}
+	}
+	// 29.97 timecode of 59.94 video isn't a problem, so -end-rate doesn't change the end.
+	half := strings.NewReplacer("24000/1001", "60000/1001", "avg_frame_rate=30/1", "avg_frame_rate=30000/1001", "01:00:00:00", "01:00:00;00").Replace(string(b))
+	for _, endRate := range []string{endRateTimecode, endRateVideo} {
+		got, err := parse(half, config{end: true, endRate: endRate})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", endRate, err)
+		}
+		// 102 frames of 59.94 are 51 frames of 29.97. duration of the fixture is of 23.976,
+		// so there is the warning of nb_frames, but not of the rate.
+		if got.end != "01:00:01;20" || strings.Contains(strings.Join(got.warnings, "\n"), "-end-rate") {
+			t.Fatalf("%v: got %v %q, want 01:00:01;20 without a warning of the rate", endRate, got.end, got.warnings)
+		}
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want string
+	}{
+		{ErrMissingFrames, "ErrMissingFrames"},
+		{fmt.Errorf("%w: 25", ErrUnsupportedFPS), "ErrUnsupportedFPS"},
+		{errors.New("other"), "ErrUnknown"},
+	}
+	for _, c := range cases {
+		got := errorCode(c.err)
+		if got != c.want {
+			t.Fatalf("errorCode(%v): got %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestTimecodeForceDrop(t *testing.T) {
+	cases := []struct {
+		code string
+		add  int
+		want string
+	}{
+		{"00:00:59:23", 1, "00:01:00;02"},
+		{"00:09:59:23", 1, "00:10:00;00"},
+		{"00:00:00:00", 14382, "00:10:00;00"},
+	}
+	for _, c := range cases {
+		tc, err := NewTimecodeForceDrop(c.code, 24)
+		if err != nil {
+			t.Fatalf("NewTimecodeForceDrop(%v): %v", c.code, err)
+		}
+		tc.Add(c.add)
+		got := tc.String()
+		if got != c.want {
+			t.Fatalf("%v + %v: got %v, want %v", c.code, c.add, got, c.want)
+		}
+	}
+}
+
+func TestClassifyResolution(t *testing.T) {
+	cases := []struct {
+		width  int
+		height int
+		want   string
+	}{
+		{720, 480, "SD"},
+		{720, 576, "SD"},
+		{640, 360, "SD"},
+		{1280, 720, "HD-720"},
+		{1920, 1080, "HD"},
+		{1920, 800, "HD"},
+		{1440, 1080, "HD"},
+		{1916, 1076, "HD"},
+		{2048, 1080, "DCI-2K"},
+		{1998, 1080, "DCI-2K"},
+		{2048, 858, "DCI-2K"},
+		{3840, 2160, "UHD-4K"},
+		{4096, 2160, "DCI-4K"},
+		{3996, 2160, "DCI-4K"},
+		{4096, 1716, "DCI-4K"},
+		{7680, 4320, "UHD-8K"},
+		{0, 1080, ""},
+	}
+	for _, c := range cases {
+		got := classifyResolution(c.width, c.height)
+		if got != c.want {
+			t.Fatalf("classifyResolution(%v, %v): got %v, want %v", c.width, c.height, got, c.want)
+		}
+	}
+}
+
+func TestShowEntries(t *testing.T) {
+	cases := []struct {
+		cfg  config
+		want string
+	}{
+		{config{fps: true}, "stream=index"},
+		{config{start: true}, "stream=index:stream_tags=timecode"},
+		{config{duration: true, resolution: true}, "stream=index,nb_frames,field_order,duration,r_frame_rate,width,height,sample_aspect_ratio:stream_tags=rotate:stream_side_data=rotation"},
+		{config{end: true, duration: true}, "stream=index,codec_tag_string,avg_frame_rate,nb_frames,field_order,duration,r_frame_rate:stream_tags=timecode"},
+		{config{start: true, shiftStart: true}, "stream=index,codec_tag_string,avg_frame_rate,time_base,start_pts,start_time,r_frame_rate:stream_tags=timecode"},
+		{config{cover: true}, "stream=index,codec_name,width,height:stream_disposition=attached_pic"},
+	}
+	for _, c := range cases {
+		got := showEntries(c.cfg)
+		if got != c.want {
+			t.Fatalf("showEntries(%+v): got %v, want %v", c.cfg, got, c.want)
+		}
+	}
+}
+
+func TestTimecodeNormalize(t *testing.T) {
+	cases := []struct {
+		code     string
+		base     int
+		drop     bool
+		want     string
+		adjusted bool
+	}{
+		// the nearest legal frame, ;00 is a frame after ;29, and ;01 a frame before ;02.
+		{"00:01:00;00", 30, true, "00:00:59;29", true},
+		{"00:01:00;01", 30, true, "00:01:00;02", true},
+		{"01:59:00;00", 30, true, "01:58:59;29", true},
+		{"00:01:00;01", 60, true, "00:00:59;59", true},
+		{"00:01:00;02", 60, true, "00:01:00;04", true},
+		{"00:01:00;02", 30, true, "00:01:00;02", false},
+		{"00:10:00;00", 30, true, "00:10:00;00", false},
+		{"00:01:01;00", 30, true, "00:01:01;00", false},
+		{"00:01:00:00", 30, false, "00:01:00:00", false},
+	}
+	for _, c := range cases {
+		tc, err := NewTimecode(c.code, c.base, c.drop)
+		if err != nil {
+			t.Fatalf("NewTimecode(%v): %v", c.code, err)
+		}
+		adjusted := tc.Normalize()
+		if adjusted != c.adjusted {
+			t.Fatalf("%v: got adjusted %v, want %v", c.code, adjusted, c.adjusted)
+		}
+		got := tc.String()
+		if got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.code, got, c.want)
+		}
+	}
+}
+
+func TestTimecodeAddNormalize(t *testing.T) {
+	// frames are added to the frame of the skipped number, and the result is
+	// a legal frame that Normalize doesn't move again.
+	tc, err := NewTimecode("00:01:00;00", 30, true)
+	if err != nil {
+		t.Fatalf("NewTimecode: %v", err)
+	}
+	tc.Add(2)
+	want := tc.String()
+	if tc.Normalize() {
+		t.Fatalf("got adjusted after Add, want not adjusted")
+	}
+	if got := tc.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// Add of 0 keeps the skipped number.
+	tc, err = NewTimecode("00:01:00;01", 30, true)
+	if err != nil {
+		t.Fatalf("NewTimecode: %v", err)
+	}
+	tc.Add(0)
+	if !tc.Normalize() || tc.String() != "00:01:00;02" {
+		t.Fatalf("got %v, want adjusted to 00:01:00;02", tc)
+	}
+}
+
+func TestSamplePoints(t *testing.T) {
+	start, err := NewTimecode("00:00:00;00", 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := config{}.samplePoints(start, 601, 3, 30000.0/1001)
+	want := "00:00:00;00\t0.000\n00:00:10;00\t10.010\n00:00:20;00\t20.020"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	got = config{layout: "HH:MM:SS.FF"}.samplePoints(start, 601, 3, 30000.0/1001)
+	want = "00:00:00.00\t0.000\n00:00:10.00\t10.010\n00:00:20.00\t20.020"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestParseWallClock(t *testing.T) {
+	cases := []struct {
+		s    string
+		want float64
+		ok   bool
+	}{
+		{"90.5", 90.5, true},
+		{"01:30.5", 90.5, true},
+		{"00:01:30.5", 90.5, true},
+		{"1:00:00", 3600, true},
+		{"0", 0, true},
+		{"00:60:00", 0, false},
+		{"00:01.5:00", 0, false},
+		{"-1", 0, false},
+		{"00:00:00:01", 0, false},
+		{"", 0, false},
+	}
+	for _, c := range cases {
+		got, err := parseWallClock(c.s)
+		if (err == nil) != c.ok {
+			t.Fatalf("%q: got error %v, want ok %v", c.s, err, c.ok)
+		}
+		if got != c.want {
+			t.Fatalf("%q: got %v, want %v", c.s, got, c.want)
+		}
+	}
+}
+
+func TestFrameAt(t *testing.T) {
+	cases := []struct {
+		sec   float64
+		frame int
+		tc    string
+	}{
+		{0, 0, "00:00:00;00"},
+		// 90.5 * 29.97 is 2712.29, it would be 2715 frames in 30 fps.
+		{90.5, 2712, "00:01:30;14"},
+		// an hour is 107892 frames in 29.97, that drop frame timecode labels as an hour.
+		{3600, 107892, "01:00:00;00"},
+		// 0.517 seconds is 15.49 frames, that rounds to the nearest.
+		{0.517, 15, "00:00:00;15"},
+	}
+	start, err := NewTimecode("00:00:00;00", 30, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		n, tc, err := frameAt(start, 108000, c.sec, 30000.0/1001)
+		if err != nil {
+			t.Fatalf("%v: %v", c.sec, err)
+		}
+		if n != c.frame || tc.String() != c.tc {
+			t.Fatalf("%v: got %v %v, want %v %v", c.sec, n, tc, c.frame, c.tc)
+		}
+	}
+	if _, _, err := frameAt(start, 108000, 3604, 30000.0/1001); !errors.Is(err, ErrTimecodeRange) {
+		t.Fatalf("got error %v, want %v after the end", err, ErrTimecodeRange)
+	}
+}
+
+func TestParseFrameAt(t *testing.T) {
+	cases := []struct {
+		frameAt string
+		want    string
+		wantErr error
+	}{
+		{"3", "90\t10:00:03;00", nil},
+		{"00:00:03.48", "104\t10:00:03;14", nil},
+		// nb_frames counts fields, so the mov is 105 frames.
+		{"3.6", "", ErrTimecodeRange},
+	}
+	b, err := os.ReadFile("testdata/ffprobe_4.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	for _, c := range cases {
+		got, err := parse(string(b), config{frameAt: c.frameAt})
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v: got error %v, want %v", c.frameAt, err, c.wantErr)
+		}
+		if got.frameAt != c.want {
+			t.Fatalf("%v: got %q, want %q", c.frameAt, got.frameAt, c.want)
+		}
+	}
+}
+
+func TestParseQuarters(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_4.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	got, err := parse(string(b), config{quarters: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "0%\t10:00:00;00\n25%\t10:00:00;26\n50%\t10:00:01;22\n75%\t10:00:02;18\n100%\t10:00:03;14"
+	if got.quarters != want {
+		t.Fatalf("got %q, want %q", got.quarters, want)
+	}
+	fields := map[string]string{}
+	for _, f := range got.detailFields() {
+		fields[f.name] = f.value
+	}
+	if fields["quarter_50"] != "10:00:01;22" {
+		t.Fatalf("got quarter_50 %q, want %q", fields["quarter_50"], "10:00:01;22")
+	}
+}
+
+func TestParseReel(t *testing.T) {
+	formatReel := func(s string) string {
+		return strings.Replace(s, "  Metadata:\n", "  Metadata:\n    com.apple.quicktime.reel: B002\n", 1)
+	}
+	cases := []struct {
+		file   string
+		modify func(string) string
+		want   string
+		source string
+	}{
+		{"testdata/ffprobe_7.out", nil, "B086C011", "TAG:reel_name of tmcd stream 2"},
+		// reel_name of the tmcd track is preferred to the format.
+		{"testdata/ffprobe_7.out", formatReel, "B086C011", "TAG:reel_name of tmcd stream 2"},
+		{"testdata/ffprobe_1.out", formatReel, "B002", "com.apple.quicktime.reel of the format"},
+		{"testdata/ffprobe_1.out", nil, "none", ""},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", err)
+		}
+		data := string(b)
+		if c.modify != nil {
+			data = c.modify(data)
+		}
+		got, err := parse(data, config{reel: true, explain: true})
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.file, err)
+		}
+		if got.reel != c.want {
+			t.Fatalf("%v: got %q, want %q", c.file, got.reel, c.want)
+		}
+		if c.source != "" && got.sources["reel"] != c.source {
+			t.Fatalf("%v: got source %q, want %q", c.file, got.sources["reel"], c.source)
+		}
+	}
+}
+
+func TestParseStartFrom(t *testing.T) {
+	cases := []struct {
+		startFrom string
+		start     string
+		end       string
+		wantErr   error
+	}{
+		{"01:00:00:00", "01:00:00:00", "01:00:04:05", nil},
+		{"+00:00:01:00", "00:00:01:00", "00:00:05:05", nil},
+		{"01:00:00;00", "", "", ErrInvalidTimecode},
+		{"01:00:00:24", "", "", ErrInvalidTimecode},
+	}
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	for _, c := range cases {
+		cfg := config{start: true, end: true, startFrom: c.startFrom}
+		got, err := parse(string(b), cfg)
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v: got error %v, want %v", c.startFrom, err, c.wantErr)
+		}
+		if got.start != c.start || got.end != c.end {
+			t.Fatalf("%v: got %v-%v, want %v-%v", c.startFrom, got.start, got.end, c.start, c.end)
+		}
+	}
+}
+
+func TestLookupFrameRate(t *testing.T) {
+	cases := []struct {
+		rational string
+		base     int
+		drop     bool
+		ok       bool
+	}{
+		{"24000/1001", 24, false, true},
+		{"24/1", 24, false, true},
+		{"25/1", 25, false, true},
+		{"30000/1001", 30, true, true},
+		{"60/2", 30, false, true},
+		{"60000/1001", 60, true, true},
+		{"0/0", 0, false, false},
+		{"15/1", 0, false, false},
+	}
+	for _, c := range cases {
+		r, ok := LookupFrameRate(c.rational)
+		if ok != c.ok || r.Base != c.base || r.Drop != c.drop {
+			t.Fatalf("LookupFrameRate(%v): got %+v %v", c.rational, r, ok)
+		}
+	}
+}
+
+func TestTimecodeRate(t *testing.T) {
+	cases := []struct {
+		code string
+		rate string
+		add  int
+		want string
+	}{
+		{"00:00:59;29", "30000/1001", 1, "00:01:00;02"},
+		{"00:00:59;59", "60000/1001", 1, "00:01:00;04"},
+		{"00:09:59;59", "60000/1001", 1, "00:10:00;00"},
+		{"00:00:59:24", "25/1", 1, "00:01:00:00"},
+		{"00:00:59:49", "50/1", 1, "00:01:00:00"},
+	}
+	for _, c := range cases {
+		r, ok := LookupFrameRate(c.rate)
+		if !ok {
+			t.Fatalf("unknown rate: %v", c.rate)
+		}
+		tc, err := NewTimecodeRate(c.code, r)
+		if err != nil {
+			t.Fatalf("NewTimecodeRate(%v, %v): %v", c.code, c.rate, err)
+		}
+		tc.Add(c.add)
+		got := tc.String()
+		if got != c.want {
+			t.Fatalf("%v + %v in %v: got %v, want %v", c.code, c.add, c.rate, got, c.want)
+		}
+	}
+}
+
+func TestSequenceGaps(t *testing.T) {
+	newClip := func(file, start, end string) clip {
+		s, err := NewTimecode(start, 24, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := NewTimecode(end, 24, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return clip{file: file, start: s, end: e}
+	}
+	clips := []clip{
+		newClip("c.mov", "01:00:10:00", "01:00:19:23"),
+		newClip("a.mov", "01:00:00:00", "01:00:04:23"),
+		newClip("b.mov", "01:00:05:00", "01:00:10:01"),
+		newClip("d.mov", "01:00:20:12", "01:00:30:00"),
+	}
+	got, err := sequenceGaps(clips)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sequenceGap{
+		{"a.mov", "b.mov", "ok", 0},
+		{"b.mov", "c.mov", "overlap", 2},
+		{"c.mov", "d.mov", "gap", 12},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// the clips of the caller aren't sorted.
+	if clips[0].file != "c.mov" {
+		t.Fatalf("got %v first, want c.mov", clips[0].file)
+	}
+	b := &bytes.Buffer{}
+	if err := printSequence(b, got[2:], false); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "c.mov\td.mov\tgap\t12\n" {
+		t.Fatalf("got %q", b.String())
+	}
+	b.Reset()
+	if err := printSequence(b, got[2:], true); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != `{"file":"c.mov","next":"d.mov","kind":"gap","frames":12}`+"\n" {
+		t.Fatalf("got %q", b.String())
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "Prores HQ / yuv422p10le"},
+		{"testdata/ffprobe_5.out", "HEVC Main 10 L5.1 / yuv420p10le"},
+		{"testdata/ffprobe_27.out", "H264 High 4:2:2 Intra / AVC-Intra 100 L4.1 / yuv422p10le"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{codec: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.codec != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.codec, c.want)
+		}
+	}
+}
+
+func TestParseEndPastMidnight(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	cases := []struct {
+		timecode  string
+		exclusive bool
+		want      string
+		crosses   bool
+	}{
+		// 102 frames from 23:59:50:00.
+		{"23:59:50:00", false, "23:59:54:05", false},
+		{"23:59:58:00", false, "00:00:02:05", true},
+		// the frame after the last frame of the day.
+		{"23:59:55:18", true, "00:00:00:00", true},
+		{"23:59:55:18", false, "23:59:59:23", false},
+	}
+	for _, c := range cases {
+		data := strings.ReplaceAll(string(b), "TAG:timecode=00:00:00:00", "TAG:timecode="+c.timecode)
+		got, err := parse(data, config{end: true, exclusiveEnd: c.exclusive})
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.timecode, err)
+		}
+		if got.end != c.want {
+			t.Fatalf("%v: got %v, want %v", c.timecode, got.end, c.want)
+		}
+		crosses := false
+		for _, w := range got.warnings {
+			crosses = crosses || strings.Contains(w, "crosses midnight")
+		}
+		if crosses != c.crosses {
+			t.Fatalf("%v: got warnings %q, want crossing midnight %v", c.timecode, got.warnings, c.crosses)
+		}
+	}
+}
+
+func TestTimecodeAddChecked(t *testing.T) {
+	cases := []struct {
+		code    string
+		base    int
+		drop    bool
+		add     int
+		want    string
+		wantErr error
+	}{
+		{"00:00:00:00", 24, false, 100, "00:00:04:04", nil},
+		{"23:59:59:22", 24, false, 1, "23:59:59:23", nil},
+		{"23:59:59:23", 24, false, 1, "23:59:59:23", ErrTimecodeRange},
+		{"00:00:00:10", 24, false, -11, "00:00:00:10", ErrTimecodeRange},
+		{"23:59:59;28", 30, true, 1, "23:59:59;29", nil},
+		{"23:59:59;29", 30, true, 1, "23:59:59;29", ErrTimecodeRange},
+		{"01:00:00:00", 24, false, 1 << 40, "01:00:00:00", ErrTimecodeRange},
+	}
+	for _, c := range cases {
+		tc, err := NewTimecode(c.code, c.base, c.drop)
+		if err != nil {
+			t.Fatalf("NewTimecode(%v): %v", c.code, err)
+		}
+		err = tc.AddChecked(c.add)
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v + %v: got error %v, want %v", c.code, c.add, err, c.wantErr)
+		}
+		got := tc.String()
+		if got != c.want {
+			t.Fatalf("%v + %v: got %v, want %v", c.code, c.add, got, c.want)
+		}
+	}
+}
+
+func TestParseEndDuration(t *testing.T) {
+	// ffprobe_3.out is excluded, as its timecode runs at different rate from the video.
+	files := []string{
+		"testdata/ffprobe_1.out",
+		"testdata/ffprobe_2.out",
+		"testdata/ffprobe_4.out",
+		"testdata/ffprobe_5.out",
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", file)
+		}
+		for _, exclusive := range []bool{false, true} {
+			cfg := config{start: true, end: true, duration: true, exclusiveEnd: exclusive}
+			res, err := parse(string(b), cfg)
+			if err != nil {
+				t.Fatalf("%v: parse error: %v", file, err)
+			}
+			drop := strings.Contains(res.end, ";")
+			start, err := newTimecode(res.start, res.base, drop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			end, err := newTimecode(res.end, res.base, drop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n := Diff(start, end)
+			if !exclusive {
+				n++
+			}
+			if strconv.Itoa(n) != res.duration {
+				t.Fatalf("%v: exclusive %v: %v to %v isn't duration %v", file, exclusive, res.start, res.end, res.duration)
+			}
+		}
+	}
+}
+
+func TestParseEncoder(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "Apple ProRes 422 HQ"},
+		{"testdata/ffprobe_2.out", "Blackmagic Design DaVinci Resolve Studio"},
+		{"testdata/ffprobe_6.out", "Adobe Premiere Pro 2022 (Macintosh)"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{encoder: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.encoder != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.encoder, c.want)
+		}
+	}
+}
+
+func TestCompactLine(t *testing.T) {
+	flds := []field{
+		{"start", "00:00:00:00"},
+		{"fps", "23.98"},
+		{"codec", "Prores HQ / yuv422p10le"},
+		{"samples", "00:00:00:00\t0.000"},
+	}
+	got := compactLine(flds)
+	want := `start=00:00:00:00 fps=23.98 codec="Prores HQ / yuv422p10le" samples="00:00:00:00\t0.000"`
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseCountFrames(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_7.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	cfg := config{end: true, duration: true}
+	_, err = parse(string(b), cfg)
+	if !errors.Is(err, ErrMissingFrames) {
+		t.Fatalf("got error %v, want %v", err, ErrMissingFrames)
+	}
+	cfg.countFrames = true
+	got, err := parse(string(b), cfg)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.end != "20:51:05:07" || got.duration != "84" {
+		t.Fatalf("got end %v duration %v, want end 20:51:05:07 duration 84", got.end, got.duration)
+	}
+}
+
+func TestParseDCI(t *testing.T) {
+	cases := []struct {
+		file string
+		want result
+	}{
+		{
+			file: "testdata/ffprobe_8.out",
+			want: result{
+				end:        "01:00:09:23",
+				resolution: "4096*2160",
+				class:      "DCI-4K",
+				base:       24,
+			},
+		},
+		{
+			file: "testdata/ffprobe_9.out",
+			want: result{
+				end:        "01:00:09:24",
+				resolution: "2048*1080",
+				class:      "DCI-2K",
+				base:       25,
+			},
+		},
+	}
+	cfg := config{
+		end:        true,
+		resolution: true,
+		class:      true,
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), cfg)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("got %v, want %v", got, c.want)
+		}
+	}
+}
+
+func TestParseSeconds(t *testing.T) {
+	cases := []struct {
+		file  string
+		start string
+		end   string
+	}{
+		{"testdata/ffprobe_1.out", "0.000", "4.213"},
+		{"testdata/ffprobe_2.out", "75136.895", "75140.357"},
+		{"testdata/ffprobe_3.out", "3599.996", "3603.967"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{start: true, end: true, seconds: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.start != c.start || got.end != c.end {
+			t.Fatalf("%v: got %v-%v, want %v-%v", c.file, got.start, got.end, c.start, c.end)
+		}
+	}
+}
+
+func TestParseCover(t *testing.T) {
+	cases := []struct {
+		file string
+		want result
+	}{
+		{"testdata/ffprobe_1.out", result{resolution: "1920*1080", cover: "none"}},
+		{"testdata/ffprobe_10.out", result{resolution: "1920*1080", cover: "mjpeg 600*600"}},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{resolution: true, cover: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%v: got %v, want %v", c.file, got, c.want)
+		}
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_2.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	out := strings.ReplaceAll(string(b), "23.98 fps", "15 fps")
+	out = strings.ReplaceAll(out, "r_frame_rate=24000/1001", "r_frame_rate=15/1")
+	cfg := config{fps: true}
+	got, err := parse(out, cfg)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.fps != "15" {
+		t.Fatalf("got fps %v, want 15", got.fps)
+	}
+	cfg.strict = true
+	_, err = parse(out, cfg)
+	if !errors.Is(err, ErrUnsupportedFPS) {
+		t.Fatalf("got error %v, want %v", err, ErrUnsupportedFPS)
+	}
+	if !strings.Contains(err.Error(), "15") {
+		t.Fatalf("error doesn't name the rate: %v", err)
+	}
+	_, err = parse(string(b), cfg)
+	if err != nil {
+		t.Fatalf("parse error for known rate: %v", err)
+	}
+}
+
+func TestParseHDR(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "none"},
+		{"testdata/ffprobe_11.out", "G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50) MaxCLL=1000 MaxFALL=400"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parseHDR(string(b))
+		if err != nil {
+			t.Fatalf("parseHDR error: %v", err)
+		}
+		if got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got, c.want)
+		}
+	}
+}
+
+func TestTimecodeFormat(t *testing.T) {
+	cases := []struct {
+		code   string
+		drop   bool
+		layout string
+		want   string
+	}{
+		{"13:02:03:04", false, "HH:MM:SS:FF", "13:02:03:04"},
+		{"13:02:03:04", false, "HH:MM:SS.FF", "13:02:03.04"},
+		{"13:02:03;04", true, "HH:MM:SS:FF", "13:02:03:04"},
+		{"13:02:03;04", true, "HH:MM:SS;FF", "13:02:03;04"},
+		{"13:02:03:04", false, "hh-MM-SS-FF", "01-02-03-04"},
+		{"00:02:03:04", false, "hhMMSSFF", "12020304"},
+	}
+	for _, c := range cases {
+		tc, err := NewTimecode(c.code, 30, c.drop)
+		if err != nil {
+			t.Fatalf("NewTimecode(%v): %v", c.code, err)
+		}
+		got := tc.Format(c.layout)
+		if got != c.want {
+			t.Fatalf("%v in %v: got %v, want %v", c.code, c.layout, got, c.want)
+		}
+	}
+}
+
+func TestTimecodeNegative(t *testing.T) {
+	tc, err := NewTimecode("-00:00:01:00", 24, false)
+	if err != nil {
+		t.Fatalf("NewTimecode: %v", err)
+	}
+	if got := tc.String(); got != "-00:00:01:00" {
+		t.Fatalf("got %v, want -00:00:01:00", got)
+	}
+	tc.Add(101)
+	if got := tc.String(); got != "00:00:03:05" {
+		t.Fatalf("got %v, want 00:00:03:05", got)
+	}
+
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	out := strings.ReplaceAll(string(b), "TAG:timecode=00:00:00:00", "TAG:timecode=-00:00:01:00")
+	res, err := parse(out, config{start: true, end: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if res.start != "-00:00:01:00" || res.end != "00:00:03:05" {
+		t.Fatalf("got %v-%v, want -00:00:01:00-00:00:03:05", res.start, res.end)
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_7.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	got, err := parseAll(string(b), config{})
+	if err != nil {
+		t.Fatalf("parseAll error: %v", err)
+	}
+	// the mov doesn't have nb_frames, but the others should be there.
+	want := &Info{
+		Start:      "20:51:01:20",
+		FPS:        "23.98",
+		Resolution: "1920*1080",
+		Class:      "HD",
+		Codec:      "Prores HQ / yuv422p10le",
+		Colorspace: "bt709",
+		PixFmt:     "4:2:2 10-bit limited little-endian",
+		Encoder:    "Blackmagic Design DaVinci Resolve Studio",
+		Brand:      "qt (compatible qt)",
+		Cover:      "none",
+		Reel:       "B086C011",
+		Creation:   "2023-07-17T09:06:24Z",
+	}
+	if len(got.Streams) != 3 {
+		t.Fatalf("got %v streams, want 3", len(got.Streams))
+	}
+	// streams are tested in TestParseStreamInfos.
+	got.Streams = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	// a mov that has all the fields.
+	b, err = os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	got, err = parseAll(string(b), config{})
+	if err != nil {
+		t.Fatalf("parseAll error: %v", err)
+	}
+	if got.Start != "00:00:00:00" || got.End != "00:00:04:05" || got.Duration != "102" {
+		t.Fatalf("got %v %v %v, want 00:00:00:00 00:00:04:05 102", got.Start, got.End, got.Duration)
+	}
+}
+
+func TestParseRefRate(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_1.out")
+	}
+	// 102 frames of 23.976 is 4.254 seconds.
+	cases := []struct {
+		refRate string
+		end     string
+		seconds string
+	}{
+		{"", "00:00:04:05", "4.213"},
+		{"25", "00:00:04:05", "4.200"},
+		{"60000/1001", "00:00:04;14", "4.238"},
+		{"48", "00:00:04:11", "4.229"},
+	}
+	for _, c := range cases {
+		got, err := parse(string(b), config{end: true, refRate: c.refRate})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.refRate, err)
+		}
+		if got.end != c.end {
+			t.Fatalf("%v: got %v, want %v", c.refRate, got.end, c.end)
+		}
+		got, err = parse(string(b), config{end: true, seconds: true, refRate: c.refRate})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.refRate, err)
+		}
+		if got.end != c.seconds {
+			t.Fatalf("%v: got %v, want %v", c.refRate, got.end, c.seconds)
+		}
+	}
+	if _, err := parse(string(b), config{end: true, refRate: "23"}); !errors.Is(err, ErrUnsupportedFPS) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedFPS)
+	}
+}
+
+func TestTmcdStart(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_35.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_35.out")
+	}
+	// the mov doesn't have the timecode tag, but the tmcd track has the frame number.
+	if _, err := parse(string(b), config{start: true}); !errors.Is(err, ErrMissingTimecode) {
+		t.Fatalf("got %v, want %v", err, ErrMissingTimecode)
+	}
+	tmcd := findTmcd(strings.SplitAfter(string(b), "[/STREAM]"))
+	if tmcd.index != 2 {
+		t.Fatalf("got tmcd index %v, want 2", tmcd.index)
+	}
+	sample, err := os.ReadFile("testdata/tmcd_35.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/tmcd_35.out")
+	}
+	cases := []struct {
+		data string
+		want string
+	}{
+		{string(sample), "01:00:00:00"},
+		// pre-roll before zero.
+		{strings.Replace(string(sample), "0001 5180", "ffff ffe8", 1), "-00:00:01:00"},
+		// 25 hours wraps to 1 hour.
+		{strings.Replace(string(sample), "0001 5180", "0020 f580", 1), "01:00:00:00"},
+	}
+	for _, c := range cases {
+		frames, err := parseTmcdFrame(c.data)
+		if err != nil {
+			t.Fatalf("parseTmcdFrame error: %v", err)
+		}
+		start, err := tmcdStart(tmcd, frames)
+		if err != nil {
+			t.Fatalf("tmcdStart error: %v", err)
+		}
+		if start != c.want {
+			t.Fatalf("got %v, want %v", start, c.want)
+		}
+		got, err := parse(string(b), config{start: true, end: true, startFrom: start})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.start != c.want {
+			t.Fatalf("got %v, want %v", got.start, c.want)
+		}
+	}
+	// without the timecode tag, a 29.97 track is drop frame. an hour of it is 107892 frames.
+	rateCases := []struct {
+		tmcd   tmcdInfo
+		frames int
+		want   string
+	}{
+		{tmcdInfo{index: 2, rate: 30000.0 / 1001}, 107892, "01:00:00;00"},
+		{tmcdInfo{index: 2, rate: 60000.0 / 1001}, 215784, "01:00:00;00"},
+		{tmcdInfo{index: 2, rate: 25}, 90000, "01:00:00:00"},
+		// the tag tells it is non-drop.
+		{tmcdInfo{index: 2, rate: 30000.0 / 1001, tagged: true}, 108000, "01:00:00:00"},
+	}
+	for _, c := range rateCases {
+		got, err := tmcdStart(c.tmcd, c.frames)
+		if err != nil {
+			t.Fatalf("tmcdStart error: %v", err)
+		}
+		if got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.tmcd.rate, got, c.want)
+		}
+	}
+	if _, err := parseTmcdFrame("data=\n00000000: 01  .\n"); err == nil {
+		t.Fatalf("want error for a short sample")
+	}
+}
+
+func TestParseStreamInfos(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_26.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_26.out")
+	}
+	got := parseStreamInfos(string(b))
+	if len(got) != 4 {
+		t.Fatalf("got %v streams, want 4", len(got))
+	}
+	want := []struct {
+		typ      string
+		codec    string
+		width    int
+		channels int
+		timecode string
+	}{
+		{"audio", "pcm_s24le", 0, 2, ""},
+		{"video", "prores", 1920, 0, "01:00:00:00"},
+		{"data", "", 0, 0, "01:00:00:00"},
+		{"data", "", 0, 0, "14:22:10:05"},
+	}
+	for i, w := range want {
+		s := got[i]
+		if s.Index != i || s.Type != w.typ || s.Codec != w.codec || s.Width != w.width || s.Channels != w.channels || s.Tags["timecode"] != w.timecode {
+			t.Fatalf("stream %v: got %+v, want %+v", i, s, w)
+		}
+		if s.Language != "und" {
+			t.Fatalf("stream %v: got language %v, want und", i, s.Language)
+		}
+	}
+}
+
+func TestParseTimecodeStream(t *testing.T) {
+	cases := []struct {
+		file   string
+		start  string
+		stream string
+	}{
+		{"testdata/ffprobe_2.out", "20:51:01:20", "0"},
+		{"testdata/ffprobe_13.out", "20:51:01:20", "2"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{start: true, timecodeStream: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.start != c.start || got.timecodeStream != c.stream {
+			t.Fatalf("%v: got %v from stream %v, want %v from stream %v", c.file, got.start, got.timecodeStream, c.start, c.stream)
+		}
+	}
+	b, err := os.ReadFile("testdata/ffprobe_13.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	_, err = parse(string(b), config{start: true, videoTimecodeOnly: true})
+	if !errors.Is(err, ErrMissingTimecode) {
+		t.Fatalf("got error %v, want %v", err, ErrMissingTimecode)
+	}
+}
+
+func TestParseChapters(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "none"},
+		{"testdata/ffprobe_14.out", "Opening\t00:00:00:00\t00:00:01:23\nAct 1\t00:00:02:00\t00:00:04:05"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{chapters: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.chapters != c.want {
+			t.Fatalf("%v: got %q, want %q", c.file, got.chapters, c.want)
+		}
+	}
+	// without a timecode, the chapters are counted from 00:00:00:00 in the rate of the mov.
+	b, err := os.ReadFile("testdata/ffprobe_14.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_14.out")
+	}
+	data := strings.ReplaceAll(string(b), "TAG:timecode=00:00:00:00\n", "")
+	got, err := parse(data, config{chapters: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.chapters != cases[1].want || len(got.warnings) != 1 {
+		t.Fatalf("got %q %q, want %q and a warning", got.chapters, got.warnings, cases[1].want)
+	}
+	// the start is still missing.
+	if _, err := parse(data, config{chapters: true, start: true}); !errors.Is(err, ErrMissingTimecode) {
+		t.Fatalf("got error %v, want %v", err, ErrMissingTimecode)
+	}
+}
+
+func TestRoundFrames(t *testing.T) {
+	cases := []struct {
+		rounding string
+		x        float64
+		want     int
+	}{
+		{"", 47.5, 48},
+		{roundHalfUp, 47.5, 48},
+		{roundHalfUp, 47.49, 47},
+		{roundFloor, 47.5, 47},
+		{roundFloor, 47.99, 47},
+		{roundFloor, 2.002 * 24000 / 1001, 48},
+	}
+	for _, c := range cases {
+		got := config{rounding: c.rounding}.roundFrames(c.x)
+		if got != c.want {
+			t.Fatalf("%v %v: got %v, want %v", c.rounding, c.x, got, c.want)
+		}
+	}
+}
+
+func TestParseComputedFrames(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_15.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	cfg := config{end: true, duration: true}
+	got, err := parse(string(b), cfg)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.end != "20:51:05:10" || got.duration != "87" || got.framesDiff != 3 || len(got.warnings) != 1 {
+		t.Fatalf("got end %v duration %v diff %v warnings %v, want end 20:51:05:10 duration 87 diff 3 and a warning", got.end, got.duration, got.framesDiff, got.warnings)
+	}
+	cfg.computedFrames = true
+	got, err = parse(string(b), cfg)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.end != "20:51:05:07" || got.duration != "84" || got.framesDiff != 3 {
+		t.Fatalf("got end %v duration %v diff %v, want end 20:51:05:07 duration 84 diff 3", got.end, got.duration, got.framesDiff)
+	}
+}
+
+func TestParsePixFmt(t *testing.T) {
+	cases := []struct {
+		name       string
+		colorRange string
+		want       string
+		wantErr    error
+	}{
+		{"yuv422p10le", "tv", "4:2:2 10-bit limited little-endian", nil},
+		{"yuv420p", "", "4:2:0 8-bit limited", nil},
+		{"yuvj420p", "", "4:2:0 8-bit full", nil},
+		{"yuvj420p", "tv", "4:2:0 8-bit limited", nil},
+		{"yuva444p12be", "", "4:4:4 12-bit limited big-endian", nil},
+		{"gbrp10le", "", "4:4:4 10-bit full little-endian", nil},
+		{"gray16le", "", "4:0:0 16-bit limited little-endian", nil},
+		{"p010le", "pc", "4:2:0 10-bit full little-endian", nil},
+		{"uyvy422", "", "4:2:2 8-bit limited", nil},
+		{"yuv4p", "", "", ErrUnknownPixFmt},
+		{"", "", "", ErrUnknownPixFmt},
+	}
+	for _, c := range cases {
+		got, err := parsePixFmt(c.name, c.colorRange)
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v: got error %v, want %v", c.name, err, c.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if got.String() != c.want {
+			t.Fatalf("%v: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	cases := []struct {
+		defaults string
+		args     []string
+		start    bool
+		retries  int
+		wantErr  bool
+	}{
+		{"-start -retries=2", []string{"a.mov"}, true, 2, false},
+		{"-start -retries=2", []string{"-retries", "3", "a.mov"}, true, 3, false},
+		{"-start", []string{"-start=false", "a.mov"}, false, 0, false},
+		{"", []string{"-start", "a.mov"}, true, 0, false},
+		{"-retries 2", []string{"a.mov"}, false, 0, true},
+	}
+	for _, c := range cases {
+		fs := flag.NewFlagSet("movinfo", flag.ContinueOnError)
+		start := fs.Bool("start", false, "")
+		retries := fs.Int("retries", 0, "")
+		err := parseFlags(fs, c.defaults, c.args)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%q %v: got error %v, want error %v", c.defaults, c.args, err, c.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if *start != c.start || *retries != c.retries || fs.Arg(0) != "a.mov" {
+			t.Fatalf("%q %v: got start %v retries %v args %v, want start %v retries %v", c.defaults, c.args, *start, *retries, fs.Args(), c.start, c.retries)
+		}
+	}
+}
+
+func TestBitrateStats(t *testing.T) {
+	// 1.5 seconds of 24 fps, 500000 bytes per frame, except a big frame in the first second.
+	lines := []string{"N/A,100"}
+	for i := 0; i < 36; i++ {
+		size := 500000
+		if i == 0 {
+			size = 2900000
+		}
+		lines = append(lines, fmt.Sprintf("%.6f,%d", float64(i)/24, size))
+	}
+	peak, avg, err := bitrateStats(strings.NewReader(strings.Join(lines, "\n")), 1.5)
+	if err != nil {
+		t.Fatalf("bitrateStats error: %v", err)
+	}
+	if math.Abs(peak-115.2e6) > 1e-3 || math.Abs(avg-108.8e6) > 1e-3 {
+		t.Fatalf("got peak %v avg %v, want peak 115.2e6 avg 108.8e6", peak, avg)
+	}
+	got := bitrateIntervals(25, 10)
+	if got != "0%+1,10%+1,20%+1" {
+		t.Fatalf("got intervals %v, want 0%%+1,10%%+1,20%%+1", got)
+	}
+}
+
+func TestParseOverviewDuration(t *testing.T) {
+	cases := []struct {
+		overview string
+		want     float64
+		ok       bool
+	}{
+		{"  Duration: 00:00:04.25, start: 0.000000, bitrate: 178198 kb/s\n", 4.25, true},
+		{"  Duration: 01:02:03.04, start: 0.000000, bitrate: N/A\n", 3723.04, true},
+		{"  Duration: N/A, start: 0.000000, bitrate: N/A\n", 0, false},
+		{"Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'a.mov':\n", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseOverviewDuration(c.overview)
+		if ok != c.ok || math.Abs(got-c.want) > 1e-9 {
+			t.Fatalf("%q: got %v %v, want %v %v", c.overview, got, ok, c.want, c.ok)
+		}
+	}
+	// the overview has the duration in 1/100 seconds.
+	for _, spec := range []fixtureSpec{
+		{rate: "25/1", frames: 250},
+		{rate: "60000/1001", frames: 3600},
+		{rate: "24000/1001", frames: 86400},
+	} {
+		r, _ := parseRate(spec.rate)
+		want := float64(spec.frames) / r
+		got, ok := parseOverviewDuration(spec.text())
+		if !ok || math.Abs(got-want) > 0.005 {
+			t.Fatalf("%+v: got %v %v, want %v true", spec, got, ok, want)
+		}
+	}
+	// the video has neither nb_frames nor duration.
+	b, err := os.ReadFile("testdata/ffprobe_39.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_39.out")
+	}
+	got, err := parse(string(b), config{end: true, duration: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.end != "00:00:04:05" || got.duration != "102" || len(got.warnings) != 1 {
+		t.Fatalf("got %v %v %v, want 00:00:04:05 102 and a warning", got.end, got.duration, got.warnings)
+	}
+	// the fallback is only for the fields that need frames.
+	for _, cfg := range []config{{fps: true}, {start: true}, {resolution: true}} {
+		got, err := parse(string(b), cfg)
+		if err != nil {
+			t.Fatalf("%+v: parse error: %v", cfg, err)
+		}
+		if len(got.warnings) != 0 {
+			t.Fatalf("%+v: got warnings %v, want none", cfg, got.warnings)
+		}
+	}
+}
+
+func TestParseDropMode(t *testing.T) {
+	// 3600 frames from 01:00:00:00, in true 30 and in 29.97.
+	cases := []struct {
+		file  string
+		mode  string
+		start string
+		end   string
+	}{
+		{"testdata/ffprobe_37.out", dropAuto, "01:00:00:00", "01:01:59:29"},
+		{"testdata/ffprobe_38.out", dropAuto, "01:00:00;00", "01:02:00;03"},
+		{"testdata/ffprobe_37.out", dropOn, "01:00:00;00", "01:02:00;03"},
+		{"testdata/ffprobe_38.out", dropOff, "01:00:00:00", "01:01:59:29"},
+		// 23.976 doesn't have drop frame, even when forced.
+		{"testdata/ffprobe_1.out", dropOn, "00:00:00:00", "00:00:04:05"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", c.file)
+		}
+		got, err := parse(string(b), config{start: true, end: true, dropMode: c.mode})
+		if err != nil {
+			t.Fatalf("%v %v: parse error: %v", c.file, c.mode, err)
+		}
+		if got.start != c.start || got.end != c.end {
+			t.Fatalf("%v %v: got %v %v, want %v %v", c.file, c.mode, got.start, got.end, c.start, c.end)
+		}
+		if len(got.warnings) != 0 {
+			t.Fatalf("%v %v: got warnings %v", c.file, c.mode, got.warnings)
+		}
+	}
+}
+
+func TestParseStandard(t *testing.T) {
+	cases := []struct {
+		file     string
+		standard string
+		want     string
+	}{
+		{"testdata/ffprobe_1.out", "atsc-hd", "ok"},
+		{"testdata/ffprobe_4.out", "atsc-hd", "ok"},
+		{"testdata/ffprobe_5.out", "atsc-uhd", "ok"},
+		{"testdata/ffprobe_1.out", "ebu-hd", "HD progressive 23.98 doesn't conform to ebu-hd (HD interlaced 25, HD progressive 25, HD progressive 50, HD-720 progressive 50)"},
+		// 1080p59.94 isn't in ATSC 1.0.
+		{"testdata/ffprobe_3.out", "atsc-hd", "HD progressive 59.94 doesn't conform to atsc-hd (" + formatList(standards["atsc-hd"]) + ")"},
+		{"testdata/ffprobe_5.out", "ebu-uhd", "UHD-4K progressive 23.98 doesn't conform to ebu-uhd (UHD-4K progressive 25, UHD-4K progressive 50)"},
+		{"testdata/ffprobe_9.out", "ebu-hd", "DCI-2K progressive 25 doesn't conform to ebu-hd (HD interlaced 25, HD progressive 25, HD progressive 50, HD-720 progressive 50)"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", c.file)
+		}
+		got, err := parse(string(b), config{standard: c.standard})
+		if err != nil {
+			t.Fatalf("%v %v: parse error: %v", c.file, c.standard, err)
+		}
+		if got.standard != c.want {
+			t.Fatalf("%v %v: got %v, want %v", c.file, c.standard, got.standard, c.want)
+		}
+	}
+	// scan isn't checked when the field order is unknown.
+	rate, _ := lookupFPS("25")
+	if got := checkStandard("ebu-hd", "HD", "unknown", rate); got != "" {
+		t.Fatalf("got %v, want no problem", got)
+	}
+	if got, want := checkStandard("ebu-sd", "HD", "", rate), "HD 25 doesn't conform to ebu-sd (SD interlaced 25)"; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// formatList joins the broadcast formats with commas.
+func formatList(formats []broadcastFormat) string {
+	s := []string{}
+	for _, f := range formats {
+		s = append(s, f.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestParseDetelecineEnd(t *testing.T) {
+	cases := []struct {
+		file     string
+		scanType string
+		want     string
+	}{
+		// 3600 frames of 29.97 from 01:00:00;00 are 2880 frames of film.
+		{"testdata/ffprobe_38.out", "telecine (3:2 pulldown)", "literal 01:02:00;03, detelecined 01:01:59:23"},
+		{"testdata/ffprobe_38.out", "progressive", "literal 01:02:00;03, not telecine (progressive)"},
+		{"testdata/ffprobe_37.out", "telecine (3:2 pulldown)", "literal 01:01:59:29, detelecined 01:01:59:23"},
+		{"testdata/ffprobe_1.out", "progressive", "literal 00:00:04:05, not telecine (23.98 fps)"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", c.file)
+		}
+		got, err := parse(string(b), config{detelecineEnd: true})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.file, err)
+		}
+		if s := got.telecine.format(c.scanType); s != c.want {
+			t.Fatalf("%v %v: got %v, want %v", c.file, c.scanType, s, c.want)
+		}
+	}
+	// film frames keep the time of the start.
+	start, err := NewTimecode("00:59:59;15", 30, true)
+	if err != nil {
+		t.Fatalf("NewTimecode error: %v", err)
+	}
+	end, err := filmEnd(start, 5, config{})
+	if err != nil {
+		t.Fatalf("filmEnd error: %v", err)
+	}
+	if end.String() != "00:59:59:15" {
+		t.Fatalf("got %v, want 00:59:59:15", end)
+	}
+}
+
+func TestKeyframeBefore(t *testing.T) {
+	// keyframes every 2 seconds of 25 fps, in decoding order with B-frames.
+	packets := "0.000000,K__\n0.120000,___\n0.040000,___\n2.000000,K__\n2.080000,___\n4.000000,K_D\nN/A,K__\n6.000000,K__\n"
+	times, err := readKeyframes(strings.NewReader(packets))
+	if err != nil {
+		t.Fatalf("readKeyframes error: %v", err)
+	}
+	if want := []float64{0, 2, 4, 6}; !reflect.DeepEqual(times, want) {
+		t.Fatalf("got %v, want %v", times, want)
+	}
+	cases := []struct {
+		target float64
+		want   int
+	}{
+		{0, 0},
+		{1.96, 0},
+		// within half a frame of the keyframe.
+		{1.99, 50},
+		{2, 50},
+		{5.5, 100},
+		{100, 150},
+	}
+	for _, c := range cases {
+		got, err := lastKeyframe(times, c.target, 25)
+		if err != nil {
+			t.Fatalf("%v: lastKeyframe error: %v", c.target, err)
+		}
+		if got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.target, got, c.want)
+		}
+	}
+	// the first keyframe is later than the target.
+	if _, err := lastKeyframe([]float64{0.5, 2.5}, 0.2, 25); !errors.Is(err, ErrNoKeyframe) {
+		t.Fatalf("got %v, want %v", err, ErrNoKeyframe)
+	}
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_1.out")
+	}
+	got, err := parse(string(b), config{keyframeBefore: "00:00:02:12"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	n, err := lastKeyframe([]float64{0, 2.002}, got.keyframe.target, got.keyframe.rate)
+	if err != nil {
+		t.Fatalf("lastKeyframe error: %v", err)
+	}
+	tc := *got.keyframe.start
+	tc.Add(n)
+	if tc.String() != "00:00:02:00" {
+		t.Fatalf("got %v, want 00:00:02:00", tc.String())
+	}
+	if _, err := parse(string(b), config{keyframeBefore: "00:00:02:24"}); !errors.Is(err, ErrInvalidTimecode) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidTimecode)
+	}
+}
+
+func TestDetectScanType(t *testing.T) {
+	// frames makes n frames of the pattern, that repeats.
+	frames := func(n int, pattern ...string) string {
+		lines := []string{}
+		for i := 0; i < n; i++ {
+			lines = append(lines, pattern[i%len(pattern)])
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+	const (
+		prog    = "interlaced_frame=0|top_field_first=0|repeat_pict=0"
+		rff     = "interlaced_frame=0|top_field_first=1|repeat_pict=1"
+		rffBFF  = "interlaced_frame=0|top_field_first=0|repeat_pict=1"
+		tff     = "interlaced_frame=1|top_field_first=1|repeat_pict=0"
+		bff     = "interlaced_frame=1|top_field_first=0|repeat_pict=0"
+		reorder = "repeat_pict=0|top_field_first=1|interlaced_frame=1"
+	)
+	cases := []struct {
+		data string
+		want string
+	}{
+		{frames(120, prog), "progressive"},
+		{frames(120, tff), "interlaced (top field first)"},
+		{frames(120, bff), "interlaced (bottom field first)"},
+		{frames(120, reorder), "interlaced (top field first)"},
+		// soft telecine of 23.976 film flagged as 29.97.
+		{frames(120, rff, prog, rffBFF, prog), "telecine (3:2 pulldown)"},
+		// hard telecine flagged per frame, 3 progressive and 2 combed frames.
+		{frames(120, prog, prog, prog, tff, tff), "telecine (3:2 pulldown)"},
+		// a cut breaks the cadence once.
+		{frames(57, prog, prog, prog, tff, tff) + frames(63, prog, prog, tff, tff, prog), "telecine (3:2 pulldown)"},
+		{frames(60, prog) + frames(60, tff), "mixed"},
+		{frames(3, rff, prog), "progressive"},
+	}
+	for i, c := range cases {
+		f, err := readScanFrames(strings.NewReader(c.data))
+		if err != nil {
+			t.Fatalf("%v: readScanFrames error: %v", i, err)
+		}
+		if got := detectScanType(f); got != c.want {
+			t.Fatalf("%v: got %v, want %v", i, got, c.want)
+		}
+	}
+	if _, err := readScanFrames(strings.NewReader("")); err == nil {
+		t.Fatalf("want error for no frames")
+	}
+}
+
+func TestCountPictTypes(t *testing.T) {
+	// two 12 frames GOPs of IBBP pattern, and a sprite frame.
+	lines := []string{}
+	for g := 0; g < 2; g++ {
+		lines = append(lines, "I")
+		for i := 0; i < 11; i++ {
+			if i%3 == 2 {
+				lines = append(lines, "P")
+			} else {
+				lines = append(lines, "B,")
+			}
+		}
+	}
+	lines = append(lines, "S", "")
+	g, err := countPictTypes(strings.NewReader(strings.Join(lines, "\n")))
+	if err != nil {
+		t.Fatalf("countPictTypes error: %v", err)
+	}
+	want := "I 2, P 6, B 16, other 1, I-frame ratio 8.0%"
+	if g.String() != want {
+		t.Fatalf("got %v, want %v", g, want)
+	}
+	if _, err := countPictTypes(strings.NewReader("")); err == nil {
+		t.Fatalf("got nil error for no frames, want error")
+	}
+}
+
+func TestGOPStructure(t *testing.T) {
+	const (
+		idr = "key_frame=1|pict_type=I"
+		i   = "key_frame=0|pict_type=I"
+		p   = "key_frame=0|pict_type=P"
+		b   = "key_frame=0|pict_type=B"
+	)
+	// gop makes a GOP of n frames in display order, starting with the first frame,
+	// followed by B B P patterns. A closed GOP ends with P.
+	gop := func(n int, first string, closed bool) []string {
+		lines := []string{first}
+		for k := 1; k < n; k++ {
+			if k%3 == 0 || closed && k == n-1 {
+				lines = append(lines, p)
+			} else {
+				lines = append(lines, b)
+			}
+		}
+		return lines
+	}
+	join := func(gops ...[]string) string {
+		lines := []string{}
+		for _, g := range gops {
+			lines = append(lines, g...)
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+	cases := []struct {
+		data string
+		want string
+	}{
+		{join(gop(24, idr, true), gop(24, idr, true), gop(24, idr, true)), "closed, GOP 24 frames, 0 scene cuts"},
+		{join(gop(24, idr, false), gop(24, i, false), gop(24, i, false)), "open, GOP 24 frames, 0 scene cuts"},
+		// extra I frames at two scene cuts.
+		{join(gop(24, idr, true), gop(10, idr, true), gop(24, idr, true), gop(5, idr, true), gop(24, idr, true), gop(24, idr, true)), "closed, GOP 24 frames, 2 scene cuts"},
+		// all intra.
+		{join(gop(1, idr, true), gop(1, idr, true), gop(1, idr, true)), "closed, GOP 1 frames, 0 scene cuts"},
+		{join(gop(30, idr, false)), "closed, GOP 30 frames, 0 scene cuts"},
+	}
+	for n, c := range cases {
+		f, err := readGOPFrames(strings.NewReader(c.data))
+		if err != nil {
+			t.Fatalf("%v: readGOPFrames error: %v", n, err)
+		}
+		if got := gopStructure(f); got != c.want {
+			t.Fatalf("%v: got %v, want %v", n, got, c.want)
+		}
+	}
+	if _, err := readGOPFrames(strings.NewReader("")); err == nil {
+		t.Fatalf("want error for no frames")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		since   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"24h", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), false},
+		{"90m", time.Date(2024, 5, 2, 10, 30, 0, 0, time.UTC), false},
+		{"2024-05-01T09:00:00+09:00", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), false},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, c := range cases {
+		got, err := parseSince(c.since, now)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%v: got error %v, want error %v", c.since, err, c.wantErr)
+		}
+		if !got.Equal(c.want) {
+			t.Fatalf("%v: got %v, want %v", c.since, got, c.want)
+		}
+	}
+	fi, err := os.Stat("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		since time.Time
+		want  bool
+	}{
+		{fi.ModTime(), true},
+		{fi.ModTime().Add(-time.Second), true},
+		{fi.ModTime().Add(time.Second), false},
+	} {
+		got, err := modifiedSince("testdata/ffprobe_1.out", c.since)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Fatalf("modified since %v: got %v, want %v", c.since, got, c.want)
+		}
+	}
+}
+
+func TestParseTrim(t *testing.T) {
+	cases := []struct {
+		file    string
+		trim    string
+		start   float64
+		length  float64
+		wantErr error
+	}{
+		{"testdata/ffprobe_4.out", "10:00:01;00,10:00:02;29", 1.001, 2.002, nil},
+		{"testdata/ffprobe_1.out", "00:00:00:00,00:00:04:05", 0, 4.254, nil},
+		{"testdata/ffprobe_1.out", "00:00:01:00,00:00:04:06", 0, 0, ErrTimecodeRange},
+		{"testdata/ffprobe_4.out", "10:00:01:00,10:00:02:29", 0, 0, ErrInvalidTimecode},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{trim: c.trim})
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v: got error %v, want %v", c.trim, err, c.wantErr)
+		}
+		if formatSeconds(got.trimStart) != formatSeconds(c.start) || formatSeconds(got.trimLength) != formatSeconds(c.length) {
+			t.Fatalf("%v: got %v+%v, want %v+%v", c.trim, got.trimStart, got.trimLength, c.start, c.length)
+		}
+	}
+	got := trimCommand("/mnt/it's here.mov", 1.001, 2.002)
+	want := `ffmpeg -ss 1.001 -i '/mnt/it'\''s here.mov' -t 2.002 'it'\''s here_trim.mov'`
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseNoStream(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_16.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	_, err = parse(string(b), config{start: true})
+	if !errors.Is(err, ErrNoStream) {
+		t.Fatalf("got error %v, want %v", err, ErrNoStream)
+	}
+	if !strings.Contains(err.Error(), "JSON") || !strings.Contains(err.Error(), "\t\"streams\": [") || !strings.HasSuffix(err.Error(), "\t...") {
+		t.Fatalf("error doesn't have a hint and the first lines: %v", err)
+	}
+}
+
+func TestParseEmptyOutput(t *testing.T) {
+	for _, data := range []string{"", "\n", " \t\r\n\n"} {
+		_, err := parse(data, config{start: true})
+		if !errors.Is(err, ErrEmptyOutput) {
+			t.Fatalf("got error %v, want %v for %q", err, ErrEmptyOutput, data)
+		}
+		_, err = parseStartOnly(data, config{start: true})
+		if !errors.Is(err, ErrEmptyOutput) {
+			t.Fatalf("got error %v, want %v for %q from parseStartOnly", err, ErrEmptyOutput, data)
+		}
+	}
+}
+
+func TestCleanTimecodeTag(t *testing.T) {
+	cases := []struct {
+		tag  string
+		want string
+	}{
+		{"01:00:00:00", "01:00:00:00"},
+		{"01:00:00:00 ", "01:00:00:00"},
+		{"\t1:00:00;00\r", "01:00:00;00"},
+		{"-0:0:1:12", "-00:00:01:12"},
+		{"1:00:00.5", "01:00:00.05"},
+		{"01:00:00:000", "01:00:00:000"},
+		{"N/A", "N/A"},
+	}
+	for _, c := range cases {
+		if got := cleanTimecodeTag(c.tag, false); got != c.want {
+			t.Fatalf("%q: got %q, want %q", c.tag, got, c.want)
+		}
+		if got := cleanTimecodeTag(c.tag, true); got != c.tag {
+			t.Fatalf("%q: got %q for strict, want it as it is", c.tag, got)
+		}
+	}
+}
+
+func TestParseTimecodeTagLength(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_44.out", "01:00:00;00"},
+		{"testdata/ffprobe_45.out", "00:00:10:00"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{start: true})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.file, err)
+		}
+		if got.start != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.start, c.want)
+		}
+		start, err := parseStartOnly(string(b), config{start: true})
+		if err != nil || start != c.want {
+			t.Fatalf("%v: got %v, %v from parseStartOnly, want %v", c.file, start, err, c.want)
+		}
+		cfg := config{start: true, strictTimecodeLength: true}
+		if _, err := parse(string(b), cfg); !errors.Is(err, ErrInvalidTimecode) {
+			t.Fatalf("%v: got error %v, want %v with -strict-timecode-length", c.file, err, ErrInvalidTimecode)
+		}
+		if _, err := parseStartOnly(string(b), cfg); !errors.Is(err, ErrInvalidTimecode) {
+			t.Fatalf("%v: got error %v from parseStartOnly, want %v with -strict-timecode-length", c.file, err, ErrInvalidTimecode)
+		}
+	}
+}
+
+func TestParseCheckDrop(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_4.out", "ok"},
+		{"testdata/ffprobe_1.out", "ok"},
+		{"testdata/ffprobe_17.out", "10:00:00:00 uses non-drop frame notation for 29.97 fps, which is usually drop frame"},
+		{"testdata/ffprobe_18.out", "10:01:00;00 is a frame that drop frame timecode skips"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{checkDrop: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.dropCheck != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.dropCheck, c.want)
+		}
+	}
+	got := checkDrop("01:00:00;00", FrameRate{Num: 25, Den: 1, Base: 25})
+	want := "01:00:00;00 uses drop frame notation, but 25 fps isn't a drop frame rate"
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestSegmentChain(t *testing.T) {
+	parseClip := func(file string) clip {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", file)
+		}
+		res, err := parse(string(b), config{start: true, end: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		c, err := newClip(file, res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	cases := []struct {
+		files []string
+		want  string
+	}{
+		{[]string{"testdata/ffprobe_4.out", "testdata/ffprobe_19.out"}, "continuous\t10:00:00;00\t10:00:06;29"},
+		{[]string{"testdata/ffprobe_4.out", "testdata/ffprobe_19.out", "testdata/ffprobe_4.out"}, "discontinuous\ttestdata/ffprobe_19.out\ttestdata/ffprobe_4.out\texpected 10:00:07;00\tgot 10:00:00;00"},
+	}
+	for _, c := range cases {
+		clips := []clip{}
+		for _, f := range c.files {
+			clips = append(clips, parseClip(f))
+		}
+		got, err := segmentChain(clips)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Fatalf("%v: got %q, want %q", c.files, got, c.want)
+		}
+	}
+	// the next frame of 00:00:59;29 is 00:01:00;02 in drop frame.
+	s1, _ := NewTimecode("00:00:50;00", 30, true)
+	e1, _ := NewTimecode("00:00:59;29", 30, true)
+	s2, _ := NewTimecode("00:01:00;02", 30, true)
+	e2, _ := NewTimecode("00:01:10;00", 30, true)
+	got, err := segmentChain([]clip{{"a.mov", s1, e1}, {"b.mov", s2, e2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "continuous\t00:00:50;00\t00:01:10;00" {
+		t.Fatalf("got %q, want continuous", got)
+	}
+}
+
+func TestParseStartOnly(t *testing.T) {
+	files, err := filepath.Glob("testdata/ffprobe_*.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", f)
+		}
+		for _, cfg := range []config{{start: true}, {start: true, videoTimecodeOnly: true}} {
+			want, wantErr := parse(string(b), cfg)
+			got, err := parseStartOnly(string(b), cfg)
+			if errorCode(err) != errorCode(wantErr) {
+				t.Fatalf("%v: got error %v, want %v", f, err, wantErr)
+			}
+			if got != want.start {
+				t.Fatalf("%v: got %v, want %v", f, got, want.start)
+			}
+		}
+	}
+	if !startOnly(config{start: true, retries: 2, ffprobeArgs: []string{"-probesize", "50M"}}) || startOnly(config{start: true, layout: "HH:MM:SS.FF"}) || startOnly(config{start: true, end: true}) || startOnly(config{start: true, shiftStart: true}) || startOnly(config{start: true, limits: limits{minClass: "HD"}}) {
+		t.Fatalf("startOnly doesn't match only -start")
+	}
+}
+
+func TestFormatHumanDuration(t *testing.T) {
+	cases := []struct {
+		sec  float64
+		want string
+	}{
+		{0, "0s"},
+		{0.5, "0.5s"},
+		{0.04, "0s"},
+		{4.254, "4.3s"},
+		{10, "10s"},
+		{83.417, "1m23.4s"},
+		{59.96, "1m0s"},
+		{3600, "1h0m0s"},
+		{3720.5, "1h2m0.5s"},
+		{90061.2, "25h1m1.2s"},
+	}
+	for _, c := range cases {
+		got := formatHumanDuration(c.sec)
+		if got != c.want {
+			t.Fatalf("formatHumanDuration(%v): got %v, want %v", c.sec, got, c.want)
+		}
+	}
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	res, err := parse(string(b), config{humanDuration: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if res.humanDuration != "4.3s" {
+		t.Fatalf("got %v, want 4.3s", res.humanDuration)
+	}
+}
+
+func TestParseStereo3D(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "2D"},
+		{"testdata/ffprobe_20.out", "side by side"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{stereo3D: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.stereo3D != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.stereo3D, c.want)
+		}
+	}
+	got := parseStereo3D("[SIDE_DATA]\nside_data_type=Stereo 3D\ntype=top and bottom\ninverted=1\n[/SIDE_DATA]\n")
+	if got != "top and bottom (inverted)" {
+		t.Fatalf("got %v, want top and bottom (inverted)", got)
+	}
+}
+
+func TestParseProjection(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "none"},
+		{"testdata/ffprobe_20.out", "none"},
+		{"testdata/ffprobe_46.out", "equirectangular (360)"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{projection: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.projection != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.projection, c.want)
+		}
+	}
+	sideData := []struct {
+		data string
+		want string
+	}{
+		{"side_data_type=Spherical Mapping\nprojection=cubemap\npadding=0\n", "cubemap (360)"},
+		// a quarter of the width is cropped from each side, so it is VR180.
+		{"side_data_type=Spherical Mapping\nprojection=tiled equirectangular\nbound_left=1073741824\nbound_top=0\nbound_right=1073741824\nbound_bottom=0\n", "tiled equirectangular (180)"},
+		{"side_data_type=Spherical Mapping\nprojection=fisheye\n", "fisheye"},
+	}
+	for _, c := range sideData {
+		if got := parseProjection("[SIDE_DATA]\n" + c.data + "[/SIDE_DATA]\n"); got != c.want {
+			t.Fatalf("got %v, want %v", got, c.want)
+		}
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	cases := []struct {
+		cfg  config
+		all  bool
+		want []string
+	}{
+		{
+			config{start: true},
+			false,
+			[]string{"LC_ALL=C ffprobe -v error -show_entries stream=codec_type:stream_tags=timecode:stream_disposition=attached_pic 'my clip.mov'"},
+		},
+		{
+			config{ffprobe: "/opt/ffmpeg/bin/ffprobe", duration: true, countFrames: true, hdr: true},
+			false,
+			[]string{
+				"LC_ALL=C /opt/ffmpeg/bin/ffprobe -count_frames -show_entries stream=index,nb_frames,field_order,duration,r_frame_rate,nb_read_frames 'my clip.mov'",
+				"LC_ALL=C /opt/ffmpeg/bin/ffprobe -select_streams v:0 -read_intervals '%+#1' -show_frames 'my clip.mov'",
+			},
+		},
+		{
+			config{start: true},
+			true,
+			[]string{
+				"LC_ALL=C ffprobe -show_streams 'my clip.mov'",
+				"LC_ALL=C ffprobe -select_streams v:0 -read_intervals '%+#1' -show_frames 'my clip.mov'",
+			},
+		},
+		{
+			config{start: true, preExec: "stage --tape"},
+			false,
+			[]string{
+				"stage --tape 'my clip.mov'",
+				"LC_ALL=C ffprobe -v error -show_entries stream=codec_type:stream_tags=timecode:stream_disposition=attached_pic 'my clip.mov'",
+			},
+		},
+	}
+	for _, c := range cases {
+		got := dryRun("my clip.mov", c.cfg, c.all)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%+v: got %q, want %q", c.cfg, got, c.want)
+		}
+	}
+	// image sequences never take the start only probe.
+	got := dryRun("shot.%04d.exr", config{start: true, framerate: "24"}, false)
+	want := []string{"LC_ALL=C ffprobe -f image2 -framerate 24 -start_number ... -show_entries stream=index:stream_tags=timecode 'shot.%04d.exr'"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("image sequence: got %q, want %q", got, want)
+	}
+}
+
+func TestFormatCreationTime(t *testing.T) {
+	cases := []struct {
+		time   string
+		zone   string
+		layout string
+		want   string
+	}{
+		{"2022-07-01T08:24:37.000000Z", "", "", "2022-07-01T08:24:37Z"},
+		{"2022-07-01T08:24:37.000000Z", "Asia/Tokyo", "", "2022-07-01T17:24:37+09:00"},
+		// daylight saving time of the date.
+		{"2022-07-01T08:24:37.000000Z", "America/New_York", "2006-01-02 15:04 MST", "2022-07-01 04:24 EDT"},
+		{"2022-12-01T08:24:37.000000Z", "America/New_York", "2006-01-02 15:04 MST", "2022-12-01 03:24 EST"},
+		{"2022-07-01T08:24:37.000000Z", "-03:30", "", "2022-07-01T04:54:37-03:30"},
+		// a time without zone is UTC.
+		{"2022-07-01T08:24:37", "+09:00", "", "2022-07-01T17:24:37+09:00"},
+		{"2022-07-01 08:24:37", "UTC", "", "2022-07-01T08:24:37Z"},
+		{"2022-07-01T17:24:37+0900", "UTC", "", "2022-07-01T08:24:37Z"},
+		{"2022-07-01T23:24:37-07:00", "Asia/Tokyo", "2006-01-02", "2022-07-02"},
+	}
+	for _, c := range cases {
+		got, err := formatCreationTime(c.time, c.zone, c.layout)
+		if err != nil {
+			t.Fatalf("%v in %v: unexpected error: %v", c.time, c.zone, err)
+		}
+		if got != c.want {
+			t.Fatalf("%v in %v: got %v, want %v", c.time, c.zone, got, c.want)
+		}
+	}
+	for _, zone := range []string{"Mars/Olympus", "+25:00", "9"} {
+		if _, err := formatCreationTime("2022-07-01T08:24:37Z", zone, ""); err == nil {
+			t.Fatalf("%v: got no error, want one", zone)
+		}
+	}
+	if _, err := formatCreationTime("July 1st", "UTC", ""); err == nil {
+		t.Fatalf("got no error, want one")
+	}
+}
+
+func TestParseCreation(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	lines := []string{}
+	for _, l := range strings.Split(string(b), "\n") {
+		if !strings.Contains(l, "creation_time") {
+			lines = append(lines, l)
+		}
+	}
+	missing := strings.Join(lines, "\n")
+	cases := []struct {
+		data string
+		want string
+	}{
+		{string(b), "2022-07-01T17:24:37+09:00"},
+		// the stream's, when the format doesn't have it.
+		{strings.Replace(string(b), "    creation_time   : 2022-07-01T08:24:37.000000Z\n", "", 1), "2022-07-01T17:24:37+09:00"},
+		{missing, "none"},
+	}
+	for _, c := range cases {
+		got, err := parse(c.data, config{creation: true, timezone: "Asia/Tokyo"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.creation != c.want {
+			t.Fatalf("got %q, want %q", got.creation, c.want)
+		}
+	}
+}
+
+func TestParseFragmented(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/fragment_1.out", "fragmented"},
+		{"testdata/fragment_2.out", "progressive"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", err)
+		}
+		got, err := parseFragmented(bytes.NewReader(b))
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.file, err)
+		}
+		if got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got, c.want)
+		}
+	}
+	// an init segment of CMAF doesn't have moof, but mvex is enough.
+	b, err := os.ReadFile("testdata/fragment_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	seg := strings.Split(string(b), "type:'moof'")[0] + "\n[FORMAT]\nformat_name=mov,mp4,m4a,3gp,3g2,mj2\n[/FORMAT]\n"
+	if got, err := parseFragmented(strings.NewReader(seg)); err != nil || got != "fragmented" {
+		t.Fatalf("got %v, %v, want fragmented", got, err)
+	}
+	if got, err := parseFragmented(strings.NewReader("[FORMAT]\nformat_name=matroska,webm\n[/FORMAT]\n")); err != nil || got != "not mp4 (matroska,webm)" {
+		t.Fatalf("got %v, %v, want not mp4 (matroska,webm)", got, err)
+	}
+	if _, err := parseFragmented(strings.NewReader("")); !errors.Is(err, ErrProbe) {
+		t.Fatalf("got %v, want %v", err, ErrProbe)
+	}
+	// the trace after the first mvex or moof isn't read.
+	head := strings.SplitAfter(string(b), "type:'moof' parent:'root' sz: 1484 1250 5243210\n")[0]
+	if got, err := parseFragmented(io.MultiReader(strings.NewReader(head), iotest.ErrReader(io.ErrUnexpectedEOF))); err != nil || got != "fragmented" {
+		t.Fatalf("got %v, %v, want fragmented", got, err)
+	}
+}
+
+func TestSetTimecodeCommand(t *testing.T) {
+	cases := []struct {
+		ffmpeg string
+		force  bool
+		want   []string
+	}{
+		{"", false, []string{"ffmpeg", "-nostdin", "-hide_banner", "-n", "-i", "a b.mov", "-map", "0", "-map", "-0:2", "-c", "copy", "-metadata", "timecode=01:00:00;00", "fixed.mov"}},
+		{"/opt/bin/ffmpeg", true, []string{"/opt/bin/ffmpeg", "-nostdin", "-hide_banner", "-y", "-i", "a b.mov", "-map", "0", "-map", "-0:2", "-c", "copy", "-metadata", "timecode=01:00:00;00", "fixed.mov"}},
+	}
+	for _, c := range cases {
+		got := setTimecodeCommand(c.ffmpeg, "a b.mov", "fixed.mov", "01:00:00;00", []string{"2"}, c.force)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("got %q, want %q", got, c.want)
+		}
+	}
+	want := "ffmpeg -nostdin -hide_banner -n -i 'a b.mov' -map 0 -map -0:2 -c copy -metadata 'timecode=01:00:00;00' fixed.mov"
+	if got := quoteArgv(cases[0].want); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// a mov without a tmcd track has all its streams copied.
+	got := setTimecodeArgs("a.mov", "fixed.mov", "01:00:00:00", nil, false)
+	if strings.Contains(strings.Join(got, " "), "-0:") {
+		t.Fatalf("got %q, want no stream excluded", got)
+	}
+}
+
+func TestParseTmcdIndexes(t *testing.T) {
+	data := "[STREAM]\nindex=0\ncodec_tag_string=avc1\n[/STREAM]\n[STREAM]\nindex=1\ncodec_tag_string=mp4a\n[/STREAM]\n" +
+		"[STREAM]\nindex=2\ncodec_tag_string=tmcd\n[/STREAM]\n[STREAM]\nindex=3\ncodec_tag_string=rtmd\n[/STREAM]\n[STREAM]\nindex=4\ncodec_tag_string=tmcd\n[/STREAM]\n"
+	// rtmd of the camera metadata is kept.
+	if got := parseTmcdIndexes(data); !reflect.DeepEqual(got, []string{"2", "4"}) {
+		t.Fatalf("got %v, want [2 4]", got)
+	}
+}
+
+func TestCheckSetTimecodeOut(t *testing.T) {
+	dir := t.TempDir()
+	mov := filepath.Join(dir, "a.mov")
+	if err := os.WriteFile(mov, []byte("data"), 0644); err != nil {
+		t.Fatalf("couldn't write file: %v", err)
+	}
+	cases := []struct {
+		out     string
+		wantErr bool
+	}{
+		{filepath.Join(dir, "fixed.mov"), false},
+		{"", true},
+		{mov, true},
+		{filepath.Join(dir, ".", "sub", "..", "a.mov"), true},
+	}
+	for _, c := range cases {
+		err := checkSetTimecodeOut(mov, c.out)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%q: got error %v, want error %v", c.out, err, c.wantErr)
+		}
+	}
+}
+
+func TestSetTimecodeConfig(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: %v", err)
+	}
+	cases := []struct {
+		timecode string
+		want     string
+		wantErr  error
+	}{
+		{"01:00:00:00", "01:00:00:00", nil},
+		{"+00:00:10:00", "00:00:10:00", nil},
+		// the mov is 23.976 fps.
+		{"01:00:00:29", "", ErrInvalidTimecode},
+		{"01:00:00;00", "", ErrInvalidTimecode},
+	}
+	for _, c := range cases {
+		// -layout doesn't change the timecode to write, and other fields aren't probed.
+		got, err := parse(string(b), setTimecodeConfig(config{layout: "HHMMSSFF", end: true, hdr: true}, c.timecode))
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v: got error %v, want %v", c.timecode, err, c.wantErr)
+		}
+		// the error is of the flag the user gave.
+		if err != nil && !strings.HasPrefix(err.Error(), "-set-timecode: ") {
+			t.Fatalf("%v: got error %v, want one of -set-timecode", c.timecode, err)
+		}
+		if got.start != c.want || got.end != "" {
+			t.Fatalf("%v: got %v %v, want %v", c.timecode, got.start, got.end, c.want)
+		}
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	cleaned := []string{}
+	fake := func(file string) (string, func(), error) {
+		if file == "missing.mov" {
+			return "", nil, errors.New("not on tape")
+		}
+		path := "/staging/" + file
+		return path, func() { cleaned = append(cleaned, path) }, nil
+	}
+	path, cleanup, err := openFile("a.mov", fake)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/staging/a.mov" {
+		t.Fatalf("got %v, want /staging/a.mov", path)
+	}
+	cleanup()
+	if !reflect.DeepEqual(cleaned, []string{"/staging/a.mov"}) {
+		t.Fatalf("got cleaned %v, want [/staging/a.mov]", cleaned)
+	}
+	if _, _, err := openFile("missing.mov", fake); err == nil {
+		t.Fatalf("got no error, want one")
+	}
+	// without an opener, or a cleanup, the file is probed as is.
+	path, cleanup, err = openFile("a.mov", nil)
+	if err != nil || path != "a.mov" {
+		t.Fatalf("got %v, %v, want a.mov", path, err)
+	}
+	cleanup()
+	_, cleanup, err = openFile("a.mov", func(file string) (string, func(), error) { return file, nil, nil })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cleanup()
+}
+
+func TestProbeAllWithOpener(t *testing.T) {
+	opened := ""
+	cleaned := false
+	fake := func(file string) (string, func(), error) {
+		opened = file
+		return filepath.Join(t.TempDir(), "extracted.mov"), func() { cleaned = true }, nil
+	}
+	// ffprobe doesn't exist, but the path is still cleaned up.
+	_, err := probeAllWith(context.Background(), "masters.tar/a.mov", config{ffprobe: filepath.Join(t.TempDir(), "ffprobe")}, fake)
+	if err == nil {
+		t.Fatalf("got no error, want one")
+	}
+	if opened != "masters.tar/a.mov" || !cleaned {
+		t.Fatalf("got opened %q, cleaned %v, want masters.tar/a.mov, true", opened, cleaned)
+	}
+}
+
+func TestParseFPSFallback(t *testing.T) {
+	for _, file := range []string{"testdata/ffprobe_21.out", "testdata/ffprobe_22.out"} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", file)
+		}
+		got, err := parse(string(b), config{fps: true, end: true})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", file, err)
+		}
+		if got.fps != "23.98" || got.end != "00:00:04:05" {
+			t.Fatalf("%v: got fps %v end %v, want fps 23.98 end 00:00:04:05", file, got.fps, got.end)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	data, err := os.ReadFile("testdata/ffprobe_10.out")
+	if err != nil {
+		b.Fatal(err)
+	}
+	out := string(data)
+	cfg := config{start: true, end: true, duration: true, resolution: true, codec: true, encoder: true, cover: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := parse(out, cfg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseExplain(t *testing.T) {
+	cases := []struct {
+		file string
+		cfg  config
+		want map[string]string
+	}{
+		{
+			"testdata/ffprobe_1.out",
+			config{},
+			map[string]string{"end": "TAG:timecode of stream 1 + nb_frames", "fps": "fps of the overview", "encoder": "TAG:encoder of stream 1"},
+		},
+		{
+			"testdata/ffprobe_4.out",
+			config{startFrom: "+00:00:01;00"},
+			map[string]string{"start": "TAG:timecode of stream 0 + -start-from", "duration": "nb_frames of fields / 2"},
+		},
+		{
+			"testdata/ffprobe_7.out",
+			config{countFrames: true},
+			map[string]string{"duration": "nb_read_frames"},
+		},
+		{
+			"testdata/ffprobe_15.out",
+			config{computedFrames: true},
+			map[string]string{"duration": "duration * rate"},
+		},
+		{
+			"testdata/ffprobe_21.out",
+			config{},
+			map[string]string{"fps": "tbr of the overview"},
+		},
+		{
+			"testdata/ffprobe_22.out",
+			config{},
+			map[string]string{"fps": "r_frame_rate"},
+		},
+		{
+			"testdata/ffprobe_6.out",
+			config{encoder: true},
+			map[string]string{"encoder": "com.apple.quicktime.software of the format"},
+		},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		c.cfg.explain = true
+		got, err := parse(string(b), c.cfg)
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.file, err)
+		}
+		for k, v := range c.want {
+			if got.sources[k] != v {
+				t.Fatalf("%v: got %v source %q, want %q", c.file, k, got.sources[k], v)
+			}
+		}
+	}
+	flds := []field{{"duration", "102"}, {"codec", "Prores HQ"}}
+	got := explainFields(flds, map[string]string{"duration": "nb_frames"})
+	want := []field{{"duration", "102 (nb_frames)"}, {"codec", "Prores HQ"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// values of json stay as they are, the sources of printed fields are by themselves.
+	sources := fieldSources(flds, map[string]string{"duration": "nb_frames", "end": "TAG:timecode of stream 1 + nb_frames"})
+	if !reflect.DeepEqual(sources, map[string]string{"duration": "nb_frames"}) || flds[0].value != "102" {
+		t.Fatalf("got sources %v and duration %v, want map[duration:nb_frames] and 102", sources, flds[0].value)
+	}
+	if got := fieldSources(flds, nil); got != nil {
+		t.Fatalf("got %v, want nil", got)
+	}
+}
+
+func TestOrderFields(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_1.out")
+	}
+	res, err := parse(string(b), config{start: true, end: true, fps: true, resolution: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	cases := []struct {
+		order string
+		want  []string
+	}{
+		{"resolution,start", []string{"resolution", "start", "end", "fps"}},
+		{"fps, end,start,resolution", []string{"fps", "end", "start", "resolution"}},
+		// a field that isn't requested is skipped.
+		{"codec,end", []string{"end", "start", "fps", "resolution"}},
+	}
+	for _, c := range cases {
+		order, err := parseFieldNames(c.order)
+		if err != nil {
+			t.Fatalf("%v: parseFieldNames error: %v", c.order, err)
+		}
+		got := []string{}
+		for _, f := range orderFields(res.fields(), order) {
+			got = append(got, f.name)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%v: got %v, want %v", c.order, got, c.want)
+		}
+	}
+	if got := orderFields(res.fields(), nil); !reflect.DeepEqual(got, res.fields()) {
+		t.Fatalf("got %v, want the default order %v", got, res.fields())
+	}
+	for _, order := range []string{"start,bogus", "start,start", ""} {
+		if _, err := parseFieldNames(order); err == nil {
+			t.Fatalf("%v: want error", order)
+		}
+	}
+	// a flag name isn't a field name, the error tells the field names.
+	if _, err := parseFieldNames("human-duration"); err == nil || !strings.Contains(err.Error(), "human_duration") {
+		t.Fatalf("got %v, want an error with the field names", err)
+	}
+}
+
+func TestParseBrands(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "qt (compatible qt)"},
+		{"testdata/ffprobe_41.out", "isom (compatible isom, iso2, avc1, mp41)"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", c.file)
+		}
+		got, err := parse(string(b), config{brand: true})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.file, err)
+		}
+		if got.brand != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.brand, c.want)
+		}
+	}
+	tags := []struct {
+		tags map[string]string
+		want string
+	}{
+		{map[string]string{}, "none"},
+		{map[string]string{"major_brand": "mp42"}, "mp42"},
+		{map[string]string{"major_brand": "qt", "compatible_brands": "qt  isom"}, "qt (compatible qt, isom)"},
+	}
+	for _, c := range tags {
+		if got := parseBrands(c.tags); got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.tags, got, c.want)
+		}
+	}
+}
+
+func TestParseDisplayResolution(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "1920*1080"},
+		{"testdata/ffprobe_23.out", "coded 1920*1080, display 1080*1920"},
+		{"testdata/ffprobe_24.out", "coded 1440*1080, display 1920*1080"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{resolution: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.resolution != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.resolution, c.want)
+		}
+	}
+}
+
+func TestParseCheckAspect(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "ok"},
+		{"testdata/ffprobe_24.out", "ok"},
+		{"testdata/ffprobe_43.out", "ok"},
+		{"testdata/ffprobe_42.out", "1440*1080 is anamorphic (HDV, XDCAM HD, sar 4:3) but sar is 1:1, the display may be squished as 1440*1080 instead of 1920*1080"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{checkAspect: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.aspectCheck != c.want {
+			t.Fatalf("%v: got %v, want %v", c.file, got.aspectCheck, c.want)
+		}
+	}
+	if got := checkAspect(960, 720, ""); !strings.Contains(got, "sar is unknown") {
+		t.Fatalf("got %q for unknown sar, want a problem", got)
+	}
+}
+
+func TestParseCheckEnd(t *testing.T) {
+	cases := []struct {
+		file     string
+		timecode string
+		cfg      config
+		want     string
+	}{
+		{"testdata/ffprobe_1.out", "", config{endRate: endRateTimecode}, "ok"},
+		// nb_frames of fields is corrected before the check.
+		{"testdata/ffprobe_4.out", "", config{endRate: endRateTimecode}, "ok"},
+		{"testdata/ffprobe_47.out", "", config{endRate: endRateTimecode}, "end 01:00:04:07 isn't start 01:00:00:00 + duration 102 - 1 = 01:00:03:11, timecode track is 30 fps, but the video is 23.98 fps"},
+		{"testdata/ffprobe_47.out", "", config{endRate: endRateTimecode, exclusiveEnd: true}, "end 01:00:04:08 isn't start 01:00:00:00 + duration 102 (-exclusive-end) = 01:00:03:12, timecode track is 30 fps, but the video is 23.98 fps"},
+		// the end is counted in the rate of the video as the start.
+		{"testdata/ffprobe_47.out", "", config{endRate: endRateVideo}, "ok"},
+		// start_time doesn't make them inconsistent, start and end shift together or not at all.
+		{"testdata/ffprobe_25.out", "", config{endRate: endRateTimecode}, "ok"},
+		{"testdata/ffprobe_25.out", "", config{endRate: endRateTimecode, shiftStart: true}, "ok"},
+		{"testdata/ffprobe_1.out", "", config{exclusiveEnd: true}, "ok"},
+		// the mov crosses midnight.
+		{"testdata/ffprobe_1.out", "23:59:58:00", config{}, "ok"},
+		{"testdata/ffprobe_1.out", "23:59:55:18", config{exclusiveEnd: true}, "ok"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", err)
+		}
+		data := string(b)
+		if c.timecode != "" {
+			data = strings.ReplaceAll(data, "TAG:timecode=00:00:00:00", "TAG:timecode="+c.timecode)
+		}
+		cfg := c.cfg
+		cfg.checkEnd = true
+		got, err := parse(data, cfg)
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", c.file, err)
+		}
+		if got.endCheck != c.want {
+			t.Fatalf("%v: got %q, want %q", c.file, got.endCheck, c.want)
+		}
+	}
+}
+
+func TestParseStartOffset(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_25.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_25.out")
+	}
+	cases := []struct {
+		data  string
+		shift bool
+		start string
+		end   string
+	}{
+		// start_time isn't used by default, so end - start + 1 is duration.
+		{string(b), false, "00:00:00:00", "00:00:04:05"},
+		// video starts at 0.5005 seconds, 12 frames after the timecode.
+		{string(b), true, "00:00:00:12", "00:00:04:17"},
+		// start_pts in time_base, when start_time isn't available.
+		{strings.Replace(string(b), "start_time=0.500500", "start_time=N/A", 1), true, "00:00:00:12", "00:00:04:17"},
+		{strings.Replace(string(b), "start_pts=12012\nstart_time=0.500500", "start_pts=0\nstart_time=0.000000", 1), true, "00:00:00:00", "00:00:04:05"},
+	}
+	for _, c := range cases {
+		got, err := parse(c.data, config{start: true, end: true, shiftStart: c.shift})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.start != c.start || got.end != c.end {
+			t.Fatalf("got start %v end %v, want start %v end %v", got.start, got.end, c.start, c.end)
+		}
+	}
+	// -start-from is the first frame, it isn't shifted.
+	got, err := parse(string(b), config{start: true, end: true, shiftStart: true, startFrom: "01:00:00:00"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.start != "01:00:00:00" || got.end != "01:00:04:05" {
+		t.Fatalf("got start %v end %v, want start 01:00:00:00 end 01:00:04:05", got.start, got.end)
+	}
+}
+
+func TestFFprobeArgs(t *testing.T) {
+	cfg := config{}
+	fs := flag.NewFlagSet("movinfo", flag.ContinueOnError)
+	fs.Var(argsFlag{&cfg.ffprobeArgs}, "ffprobe-arg", "")
+	if err := fs.Parse([]string{"-ffprobe-arg=-probesize", "-ffprobe-arg", "50M", "-ffprobe-arg=-fflags", "-ffprobe-arg=+genpts"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg.start = true
+	got := ffprobeArgv(cfg.ffprobeCmd(), "a.mov", startOnlyArgs)
+	want := append([]string{"ffprobe", "-probesize", "50M", "-fflags", "+genpts"}, startOnlyArgs...)
+	want = append(want, "a.mov")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	lines := dryRun("a.mov", cfg, false)
+	if len(lines) != 1 || !strings.HasPrefix(lines[0], "LC_ALL=C ffprobe -probesize 50M -fflags +genpts -v error ") {
+		t.Fatalf("got %q, want extra args before the others", lines)
+	}
+}
+
+func TestTimecodeAddDuration(t *testing.T) {
+	rate := 30000.0 / 1001
+	cases := []struct {
+		code string
+		d    time.Duration
+		want string
+	}{
+		{"00:00:00;00", time.Second, "00:00:01;00"},
+		{"00:00:00;00", 1001 * time.Millisecond, "00:00:01;00"},
+		// a minute of wall clock is 1798.2 frames, shorter than a minute of timecode.
+		{"00:00:00;00", time.Minute, "00:00:59;28"},
+		{"00:00:00;00", 10 * time.Minute, "00:10:00;00"},
+		// half a frame rounds up to the next frame.
+		{"00:00:00;00", 16683334 * time.Nanosecond, "00:00:00;01"},
+		{"00:00:00;00", 16683332 * time.Nanosecond, "00:00:00;00"},
+		{"00:01:00;02", -time.Second / 30, "00:00:59;29"},
+	}
+	for _, c := range cases {
+		tc, err := NewTimecode(c.code, 30, true)
+		if err != nil {
+			t.Fatalf("NewTimecode(%v): %v", c.code, err)
+		}
+		tc.AddDuration(c.d, rate)
+		got := tc.String()
+		if got != c.want {
+			t.Fatalf("%v + %v: got %v, want %v", c.code, c.d, got, c.want)
+		}
+	}
+}
+
+// fixtureSpec is a declarative spec of a mov, to generate ffprobe output
+// for tests without real media.
+type fixtureSpec struct {
+	// rate is r_frame_rate of the video. (ex. 30000/1001)
+	rate     string
+	frames   int
+	width    int
+	height   int
+	codec    string
+	pixFmt   string
+	timecode string
+	// audio adds an audio stream before the video, as most movs have.
+	audio bool
+}
+
+// text generates ffprobe output of -show_streams in its default format,
+// with the overview ffprobe prints to stderr.
+func (s fixtureSpec) text() string {
+	r, err := parseRate(s.rate)
+	if err != nil {
+		panic(err)
+	}
+	fps := strconv.FormatFloat(r, 'f', 2, 64)
+	if r == float64(int(r)) {
+		fps = strconv.Itoa(int(r))
+	}
+	duration := float64(s.frames) / r
+	videoIdx := 0
+	if s.audio {
+		videoIdx = 1
+	}
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Input #0, mov,mp4,m4a,3gp,3g2,mj2, from 'generated.mov':\n")
+	cs := int(math.Round(duration * 100))
+	fmt.Fprintf(b, "  Duration: %02d:%02d:%02d.%02d, start: 0.000000, bitrate: 100000 kb/s\n", cs/360000, cs/6000%60, cs/100%60, cs%100)
+	if s.audio {
+		fmt.Fprintf(b, "  Stream #0:0(und): Audio: pcm_s24le (lpcm / 0x6D63706C), 48000 Hz, 2 channels, s32 (24 bit), 2304 kb/s (default)\n")
+	}
+	fmt.Fprintf(b, "  Stream #0:%d(und): Video: %s, %s(tv, progressive), %dx%d, 100000 kb/s, SAR 1:1, %s fps, %s tbr, %s tbn (default)\n", videoIdx, s.codec, s.pixFmt, s.width, s.height, fps, fps, fps)
+	if s.timecode != "" {
+		fmt.Fprintf(b, "    Metadata:\n      timecode        : %s\n", s.timecode)
+	}
+	if s.audio {
+		fmt.Fprintf(b, "[STREAM]\nindex=0\ncodec_name=pcm_s24le\ncodec_type=audio\nr_frame_rate=0/0\navg_frame_rate=0/0\nduration=%f\nnb_frames=%d\n[/STREAM]\n", duration, int(duration*48000))
+	}
+	fmt.Fprintf(b, "[STREAM]\nindex=%d\ncodec_name=%s\ncodec_type=video\nwidth=%d\nheight=%d\npix_fmt=%s\nfield_order=progressive\n", videoIdx, s.codec, s.width, s.height, s.pixFmt)
+	fmt.Fprintf(b, "r_frame_rate=%s\navg_frame_rate=%s\nduration=%f\nnb_frames=%d\nDISPOSITION:attached_pic=0\n", s.rate, s.rate, duration, s.frames)
+	if s.timecode != "" {
+		fmt.Fprintf(b, "TAG:timecode=%s\n", s.timecode)
+	}
+	fmt.Fprintf(b, "[/STREAM]\n")
+	return b.String()
+}
+
+// json generates ffprobe output of -show_streams -of json.
+func (s fixtureSpec) json() string {
+	tags := ""
+	if s.timecode != "" {
+		tags = fmt.Sprintf(",\n            \"tags\": {\n                \"timecode\": %q\n            }", s.timecode)
+	}
+	return fmt.Sprintf(`{
+    "streams": [
+        {
+            "index": 0,
+            "codec_name": %q,
+            "codec_type": "video",
+            "width": %d,
+            "height": %d,
+            "pix_fmt": %q,
+            "r_frame_rate": %q,
+            "nb_frames": "%d"%s
+        }
+    ]
+}
+`, s.codec, s.width, s.height, s.pixFmt, s.rate, s.frames, tags)
+}
+
+func TestFixtureSpec(t *testing.T) {
+	cases := []struct {
+		spec fixtureSpec
+		cfg  config
+		want result
+	}{
+		{
+			fixtureSpec{rate: "25/1", frames: 250, width: 1920, height: 1080, codec: "prores", pixFmt: "yuv422p10le", timecode: "10:00:00:00", audio: true},
+			config{end: true, fps: true, resolution: true, class: true},
+			result{end: "10:00:09:24", fps: "25", resolution: "1920*1080", class: "HD", base: 25},
+		},
+		{
+			fixtureSpec{rate: "60000/1001", frames: 3600, width: 3840, height: 2160, codec: "hevc", pixFmt: "yuv420p10le", timecode: "00:59:59;00"},
+			config{end: true, duration: true, pixfmt: true},
+			result{end: "01:00:58;59", duration: "3600", pixfmt: "4:2:0 10-bit limited little-endian", base: 60},
+		},
+		{
+			fixtureSpec{rate: "24000/1001", frames: 48, width: 2048, height: 858, codec: "prores", pixFmt: "yuv444p12le", timecode: "01:00:00:00", audio: true},
+			config{start: true, end: true, class: true},
+			result{start: "01:00:00:00", end: "01:00:01:23", class: "DCI-2K", base: 24},
+		},
+	}
+	for _, c := range cases {
+		got, err := parse(c.spec.text(), c.cfg)
+		if err != nil {
+			t.Fatalf("%+v: parse error: %v", c.spec, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%+v: got %+v, want %+v", c.spec, got, c.want)
+		}
+		// movinfo doesn't read json output of ffprobe, but tells about it.
+		_, err = parse(c.spec.json(), c.cfg)
+		if !errors.Is(err, ErrNoStream) || !strings.Contains(err.Error(), "JSON") {
+			t.Fatalf("%+v: got error %v for json, want %v with a hint", c.spec, err, ErrNoStream)
+		}
+	}
+}
+
+func TestWatchDirInterval(t *testing.T) {
+	// time.Tick of 0 never ticks, so it would hang.
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if err := watchDir(t.TempDir(), interval, func(string) {}); err == nil {
+			t.Fatalf("%v: got no error, want one", interval)
+		}
+	}
+}
+
+func TestWatcherUpdate(t *testing.T) {
+	w := newWatcher(map[string]fileState{"old.mov": {100, 1}}, 2)
+	polls := []struct {
+		states map[string]fileState
+		want   []string
+	}{
+		{map[string]fileState{"old.mov": {100, 1}, "a.mov": {0, 2}}, []string{}},
+		// a.mov is growing.
+		{map[string]fileState{"old.mov": {100, 1}, "a.mov": {50, 3}}, []string{}},
+		{map[string]fileState{"old.mov": {100, 1}, "a.mov": {100, 4}, "b.mov": {10, 4}}, []string{}},
+		{map[string]fileState{"old.mov": {100, 1}, "a.mov": {100, 4}, "b.mov": {10, 4}}, []string{}},
+		{map[string]fileState{"old.mov": {100, 1}, "a.mov": {100, 4}, "b.mov": {10, 4}}, []string{"a.mov", "b.mov"}},
+		// found files aren't found again, even if they change.
+		{map[string]fileState{"old.mov": {200, 5}, "a.mov": {200, 5}, "b.mov": {10, 4}}, []string{}},
+		// an empty file is never ready.
+		{map[string]fileState{"c.mov": {0, 6}}, []string{}},
+		{map[string]fileState{"c.mov": {0, 6}}, []string{}},
+		{map[string]fileState{"c.mov": {0, 6}}, []string{}},
+	}
+	for i, p := range polls {
+		got := w.update(p.states)
+		if !reflect.DeepEqual(got, p.want) {
+			t.Fatalf("poll %v: got %v, want %v", i, got, p.want)
+		}
+	}
+}
+
+func TestScanDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.mov", "b.MXF", ".c.mov", "d.txt", "e.mov.part"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "f.mov"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	states, err := scanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for name, st := range states {
+		if st.size != 4 {
+			t.Fatalf("%v: got size %v, want 4", name, st.size)
+		}
+		got = append(got, name)
+	}
+	sort.Strings(got)
+	want := []string{"a.mov", "b.MXF"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseTimecodes(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "1\t00:00:00:00\t23.98\n2\t00:00:00:00\t23.98"},
+		// source and record timecode tracks.
+		{"testdata/ffprobe_26.out", "1\t01:00:00:00\t23.98\n2\t01:00:00:00\t23.98\n3\t14:22:10:05\t23.98"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{timecodes: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.timecodes != c.want {
+			t.Fatalf("%v: got %q, want %q", c.file, got.timecodes, c.want)
+		}
+	}
+}
+
+func TestRunBatch(t *testing.T) {
+	files := []string{"testdata/ffprobe_1.out", "testdata/ffprobe_bad.out", "testdata/ffprobe_2.out", "testdata/ffprobe_bad.out"}
+	cases := []struct {
+		failFast bool
+		done     []string
+		failed   []string
+	}{
+		{false, []string{"testdata/ffprobe_1.out", "testdata/ffprobe_2.out"}, []string{"testdata/ffprobe_bad.out", "testdata/ffprobe_bad.out"}},
+		{true, []string{"testdata/ffprobe_1.out"}, []string{"testdata/ffprobe_bad.out"}},
+	}
+	for _, c := range cases {
+		done := []string{}
+		errs := []error{}
+		do := func(file string) error {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if _, err := parse(string(b), config{start: true}); err != nil {
+				return err
+			}
+			done = append(done, file)
+			return nil
+		}
+		failed := runBatch(files, c.failFast, do, func(file string, err error) {
+			errs = append(errs, err)
+		})
+		if !reflect.DeepEqual(done, c.done) || !reflect.DeepEqual(failed, c.failed) {
+			t.Fatalf("failFast %v: got done %v failed %v, want done %v failed %v", c.failFast, done, failed, c.done, c.failed)
+		}
+		for _, err := range errs {
+			if !errors.Is(err, ErrNoStream) {
+				t.Fatalf("failFast %v: got error %v, want ErrNoStream", c.failFast, err)
+			}
+		}
+	}
+}
+
+func TestReadFileList(t *testing.T) {
+	list := "testdata/ffprobe_1.out\r\n\ntestdata/ffprobe_4.out\n  \ntestdata/ffprobe_bad.out"
+	files, err := readFileList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"testdata/ffprobe_1.out", "testdata/ffprobe_4.out", "testdata/ffprobe_bad.out"}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("got %q, want %q", files, want)
+	}
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, []byte(list), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files, err = loadFileList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	starts := []string{}
+	failed := runBatch(files, false, func(file string) error {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		res, err := parse(string(b), config{start: true})
+		if err != nil {
+			return err
+		}
+		starts = append(starts, file+" "+res.start)
+		return nil
+	}, func(file string, err error) {})
+	wantStarts := []string{"testdata/ffprobe_1.out 00:00:00:00", "testdata/ffprobe_4.out 10:00:00;00"}
+	if !reflect.DeepEqual(starts, wantStarts) || !reflect.DeepEqual(failed, []string{"testdata/ffprobe_bad.out"}) {
+		t.Fatalf("got %q failed %q, want %q failed [testdata/ffprobe_bad.out]", starts, failed, wantStarts)
+	}
+}
+
+func TestReadContentHash(t *testing.T) {
+	packets := func(data ...string) string {
+		lines := []string{}
+		for _, d := range data {
+			lines = append(lines, fmt.Sprintf("SHA256:%x", sha256.Sum256([]byte(d))))
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+	hash := func(out string) string {
+		h, err := readContentHash(strings.NewReader(out))
+		if err != nil {
+			t.Fatalf("readContentHash error: %v", err)
+		}
+		return h
+	}
+	a := hash(packets("frame 0", "frame 1", "frame 2"))
+	if len(a) != 64 {
+		t.Fatalf("got %v, want 64 hex digits", a)
+	}
+	// the same packets from another container, with CRLF.
+	if b := hash(strings.ReplaceAll(packets("frame 0", "frame 1", "frame 2"), "\n", "\r\n")); b != a {
+		t.Fatalf("got %v for the same packets, want %v", b, a)
+	}
+	for _, out := range []string{packets("frame 0", "frame 2", "frame 1"), packets("frame 0", "frame 1"), packets("frame 0", "frame 1", "frame X")} {
+		if b := hash(out); b == a {
+			t.Fatalf("got the same hash %v for different packets %q", b, out)
+		}
+	}
+	if _, err := readContentHash(strings.NewReader("")); err == nil {
+		t.Fatal("got no error for no packets")
+	}
+	if _, err := readContentHash(strings.NewReader("N/A\n")); err == nil {
+		t.Fatal("got no error for a packet without hash")
+	}
+}
+
+func TestPrintUsage(t *testing.T) {
+	fs := flag.NewFlagSet("movinfo", flag.ContinueOnError)
+	start := fs.Bool("start", false, "get start.")
+	fs.Bool("fps", false, "get fps.")
+	fs.Bool("hdr", false, "get hdr.")
+	fs.String("ffprobe", "ffprobe", "path of ffprobe.")
+	fs.Bool("new-flag", false, "not grouped yet.")
+	if err := fs.Parse([]string{"-start"}); err != nil || !*start {
+		t.Fatalf("parse error: %v", err)
+	}
+	b := &bytes.Buffer{}
+	if err := printUsage(b, fs, ""); err != nil {
+		t.Fatal(err)
+	}
+	sections := map[string]string{}
+	group := ""
+	for _, l := range strings.Split(b.String(), "\n") {
+		if strings.HasSuffix(l, ":") && !strings.HasPrefix(l, " ") {
+			group = strings.TrimSuffix(l, ":")
+			continue
+		}
+		sections[group] += l + "\n"
+	}
+	want := map[string][]string{
+		"timecode": {"-start"},
+		"video":    {"-fps"},
+		"color":    {"-hdr"},
+		"ffprobe":  {"-ffprobe string"},
+		"other":    {"-new-flag"},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("got groups of %q, want %v", b.String(), want)
+	}
+	for g, flags := range want {
+		for _, f := range flags {
+			if !strings.Contains(sections[g], "  "+f+"\n") {
+				t.Fatalf("group %v doesn't have %v: %q", g, f, sections[g])
+			}
+		}
+	}
+	// the default is printed, not the value set by the args.
+	if strings.Contains(b.String(), "default true") {
+		t.Fatalf("got the value of the args as the default: %q", b.String())
+	}
+	b.Reset()
+	if err := printUsage(b, fs, "video"); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "  -fps\n    \tget fps.\n" {
+		t.Fatalf("got %q for video group", got)
+	}
+	if err := printUsage(b, fs, "bogus"); err == nil {
+		t.Fatal("got no error for unknown group")
+	}
+	seen := map[string]string{}
+	for _, g := range flagGroups {
+		for _, f := range g.flags {
+			if seen[f] != "" {
+				t.Fatalf("%v is in both %v and %v", f, seen[f], g.name)
+			}
+			seen[f] = g.name
+		}
+	}
+	// the flags of fields are listed in the error of no flag, so they should be real flags.
+	for _, f := range fieldFlags {
+		if seen[f.name] == "" {
+			t.Fatalf("%v of fieldFlags isn't in any group", f.name)
+		}
+	}
+}
+
+func TestCodecDetailFields(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_27.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_27.out")
+	}
+	res, err := parse(string(b), config{codec: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	// profile has a slash itself, so it should be a field of its own.
+	want := []field{
+		{"codec_name", "H264"},
+		{"codec_profile", "High 4:2:2 Intra / AVC-Intra 100"},
+		{"codec_level", "4.1"},
+		{"codec_pix_fmt", "yuv422p10le"},
+	}
+	got := res.detailFields()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseImageSequence(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_28.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_28.out")
+	}
+	// image sequences don't have timecode, so start is given.
+	got, err := parse(string(b), config{startFrom: "01:00:00:00", start: true, end: true, duration: true, resolution: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.start != "01:00:00:00" || got.end != "01:00:01:23" || got.duration != "48" || got.resolution != "4096*2160" {
+		t.Fatalf("got %v %v %v %v, want 01:00:00:00 01:00:01:23 48 4096*2160", got.start, got.end, got.duration, got.resolution)
+	}
+}
+
+func TestSequenceArgs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"plate.1001.exr", "plate.1002.exr", "plate.10000.exr", "plate.0999.jpg", "other.0001.exr"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pattern := filepath.Join(dir, "plate.%04d.exr")
+	if !isImageSequence(pattern) || isImageSequence(filepath.Join(dir, "plate%.mov")) {
+		t.Fatalf("isImageSequence is wrong for %v", pattern)
+	}
+	got, err := sequenceArgs(pattern, "24000/1001")
+	if err != nil {
+		t.Fatalf("sequenceArgs error: %v", err)
+	}
+	want := []string{"-f", "image2", "-framerate", "24000/1001", "-start_number", "1001"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := sequenceArgs(pattern, ""); !errors.Is(err, ErrMissingFramerate) {
+		t.Fatalf("got %v, want ErrMissingFramerate", err)
+	}
+	if _, err := sequenceArgs(filepath.Join(dir, "none.%04d.exr"), "24"); err == nil {
+		t.Fatalf("got nil error for no files, want error")
+	}
+}
+
+func TestParseFramesFromMidnight(t *testing.T) {
+	cases := []struct {
+		file  string
+		start string
+		end   string
+	}{
+		// 00:00:00:00 to 00:00:04:05 in 23.976 NDF.
+		{"testdata/ffprobe_1.out", "0", "101"},
+		// 10:00:00;00 to 10:00:03;14 in 29.97 DF, 10 hours are 1078920 frames.
+		{"testdata/ffprobe_4.out", "1078920", "1079024"},
+		// 01:00:00:00 in 23.976 NDF.
+		{"testdata/ffprobe_26.out", "86400", "86501"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{start: true, end: true, midnightFrames: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.start != c.start || got.end != c.end {
+			t.Fatalf("%v: got %v %v, want %v %v", c.file, got.start, got.end, c.start, c.end)
+		}
+	}
+}
+
+func TestParseFewFrames(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_29.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_29.out")
+	}
+	// a single frame clip ends where it starts.
+	got, err := parse(string(b), config{start: true, end: true, duration: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.start != "00:00:00:00" || got.end != "00:00:00:00" || got.duration != "1" {
+		t.Fatalf("got %v %v %v, want 00:00:00:00 00:00:00:00 1", got.start, got.end, got.duration)
+	}
+	b, err = os.ReadFile("testdata/ffprobe_30.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_30.out")
+	}
+	for _, cfg := range []config{{end: true}, {duration: true}, {humanDuration: true}, {samples: 3}} {
+		_, err := parse(string(b), cfg)
+		if !errors.Is(err, ErrNoFrames) {
+			t.Fatalf("%+v: got error %v, want ErrNoFrames", cfg, err)
+		}
+	}
+	// start doesn't need frames.
+	got, err = parse(string(b), config{start: true})
+	if err != nil || got.start != "00:00:00:00" {
+		t.Fatalf("got %v %v, want 00:00:00:00", got.start, err)
+	}
+}
+
+func TestParseFormatTimecode(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_31.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_31.out")
+	}
+	// the streams have 00:00:00:00, and the format has 01:00:00:00.
+	got, err := parse(string(b), config{start: true, timecodeStream: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.start != "00:00:00:00" || got.timecodeStream != "1" || len(got.warnings) != 1 {
+		t.Fatalf("got %v %v %v, want 00:00:00:00 1 and a warning", got.start, got.timecodeStream, got.warnings)
+	}
+	noStream := strings.ReplaceAll(string(b), "TAG:timecode=00:00:00:00\n", "")
+	got, err = parse(noStream, config{start: true, timecodeStream: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.start != "01:00:00:00" || got.timecodeStream != "format" || len(got.warnings) != 0 {
+		t.Fatalf("got %v %v %v, want 01:00:00:00 format without warnings", got.start, got.timecodeStream, got.warnings)
+	}
+}
+
+func TestParseTimecodeOrder(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_40.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_40.out")
+	}
+	// the video has 00:00:10:00, the tmcd track 00:00:20:00, and the format 01:00:00:00.
+	cases := []struct {
+		order     string
+		videoOnly bool
+		start     string
+		source    string
+		stream    string
+	}{
+		{"", false, "00:00:10:00", "video", "1"},
+		{defaultTimecodeOrder, false, "00:00:10:00", "video", "1"},
+		{"streams,video", false, "00:00:20:00", "streams", "2"},
+		{"format,video", false, "01:00:00:00", "format", "format"},
+		{"format, streams", false, "01:00:00:00", "format", "format"},
+		{"format", true, "00:00:10:00", "video", "1"},
+	}
+	for _, c := range cases {
+		got, err := parse(string(b), config{start: true, timecodeSource: true, timecodeStream: true, timecodeOrder: c.order, videoTimecodeOnly: c.videoOnly})
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", c.order, err)
+		}
+		if got.start != c.start || got.timecodeSource != c.source || got.timecodeStream != c.stream {
+			t.Fatalf("%v: got %v from %v %v, want %v from %v %v", c.order, got.start, got.timecodeSource, got.timecodeStream, c.start, c.source, c.stream)
+		}
+		// the other two sources differ.
+		if len(got.warnings) != 2 {
+			t.Fatalf("%v: got warnings %v, want 2", c.order, got.warnings)
+		}
+	}
+	noFormat := strings.ReplaceAll(string(b), "    timecode        : 01:00:00:00\n", "")
+	if _, err := parse(noFormat, config{start: true, timecodeOrder: "format"}); !errors.Is(err, ErrMissingTimecode) {
+		t.Fatalf("got %v, want ErrMissingTimecode", err)
+	}
+	// the fast path for -start doesn't have the format, and leaves it to the full probe.
+	if got, err := parseStartOnly(string(b), config{start: true, timecodeOrder: "streams,video"}); err != nil || got != "00:00:20:00" {
+		t.Fatalf("got %v %v, want 00:00:20:00", got, err)
+	}
+	if _, err := parseStartOnly(string(b), config{start: true, timecodeOrder: "format,video"}); !errors.Is(err, ErrMissingTimecode) {
+		t.Fatalf("got %v, want ErrMissingTimecode", err)
+	}
+	for _, order := range []string{"video,bogus", "video,video", "tmcd-frame,video", ""} {
+		if _, err := parseTimecodeOrder(order); err == nil {
+			t.Fatalf("%v: want error", order)
+		}
+	}
+}
+
+func TestParse48FPS(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_34.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_34.out")
+	}
+	// 200 frames at 48 fps is 4 seconds and 8 frames.
+	got, err := parse(string(b), config{start: true, end: true, fps: true})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got.start != "01:00:00:00" || got.end != "01:00:04:07" || got.fps != "48" {
+		t.Fatalf("got %v %v %v, want 01:00:00:00 01:00:04:07 48", got.start, got.end, got.fps)
+	}
+	tc, err := NewTimecode("01:00:04:47", 48, false)
+	if err != nil {
+		t.Fatalf("NewTimecode error: %v", err)
+	}
+	tc.Add(1)
+	if got := tc.String(); got != "01:00:05:00" {
+		t.Fatalf("got %v, want 01:00:05:00", got)
+	}
+	if err := validateTimecode("01:00:04:48", 48, false); err == nil {
+		t.Fatalf("want error for frame 48 at 48 fps")
+	}
+}
+
+func TestParseLoudness(t *testing.T) {
+	summary := `[Parsed_ebur128_0 @ 0x7f8] t: 4.2       TARGET:-23 LUFS    M: -22.1 S: -23.4     I: -23.1 LUFS       LRA:   4.1 LU  FTPK: -2.0 dBFS  TPK: -1.2 dBFS
+[Parsed_ebur128_0 @ 0x7f8] Summary:
+
+  Integrated loudness:
+    I:         %v LUFS
+    Threshold: -33.2 LUFS
+
+  Loudness range:
+    LRA:         4.1 LU
+    Threshold: -43.2 LUFS
+    LRA low:   -25.6 LUFS
+    LRA high:  -21.5 LUFS
+
+  True peak:
+    Peak:       -1.2 dBFS
+`
+	cases := []struct {
+		out     string
+		want    string
+		wantErr bool
+	}{
+		{fmt.Sprintf(summary, "-23.1"), "-23.1 LUFS, true peak -1.2 dBTP", false},
+		{fmt.Sprintf(summary, "-70.0"), "", true},
+		{"Stream map '0:a:0' matches no streams.", "", true},
+	}
+	for _, c := range cases {
+		got, err := parseLoudness(c.out)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("got error %v, want error %v", err, c.wantErr)
+		}
+		if got != c.want {
+			t.Fatalf("got %v, want %v", got, c.want)
+		}
+	}
+}
+
+func TestParseTruncated(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_1.out")
+	}
+	data := string(b)
+	video := strings.Index(data, "codec_type=video")
+	first := strings.Index(data, "[/STREAM]") + len("[/STREAM]\n")
+	cases := []string{
+		// killed in the middle of the video stream.
+		data[:video],
+		// killed after the first stream.
+		data[:first],
+	}
+	for _, c := range cases {
+		if _, err := parse(c, config{start: true, end: true}); !errors.Is(err, ErrTruncated) {
+			t.Fatalf("got %v, want %v", err, ErrTruncated)
+		}
+	}
+	if _, err := parseStartOnly(data[:video], config{start: true}); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("got %v, want %v", err, ErrTruncated)
+	}
+	if _, err := parse(data, config{start: true, end: true}); err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+}
+
+func TestParseColorInfo(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_36.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_36.out")
+	}
+	numeric := string(b)
+	named := strings.Replace(numeric, "color_space=1\ncolor_transfer=1\ncolor_primaries=1", "color_space=bt2020nc\ncolor_transfer=unknown\ncolor_primaries=bt2020", 1)
+	cases := []struct {
+		data     string
+		want     string
+		warnings int
+	}{
+		// numeric codes only, as some legacy QuickTime has.
+		{numeric, "primaries bt709, transfer bt709, matrix bt709", 0},
+		{strings.Replace(numeric, "color_transfer=1", "color_transfer=3", 1), "primaries bt709, transfer unknown (3), matrix bt709", 0},
+		{named, "primaries bt2020, transfer unknown, matrix bt2020nc", 0},
+		// the overview fills the unknown transfer, and differs in matrix.
+		{strings.Replace(named, "yuv422p10le(tv, progressive)", "yuv422p10le(tv, bt709/bt2020/smpte2084, progressive)", 1), "primaries bt2020, transfer smpte2084, matrix bt2020nc", 1},
+		{strings.Replace(named, "yuv422p10le(tv, progressive)", "yuv422p10le(tv, bt2020nc/bt2020/unknown, progressive)", 1), "primaries bt2020, transfer unknown, matrix bt2020nc", 0},
+	}
+	for _, c := range cases {
+		got, err := parse(c.data, config{colorInfo: true})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.colorInfo != c.want || len(got.warnings) != c.warnings {
+			t.Fatalf("got %v %q, want %v and %v warnings", got.colorInfo, got.warnings, c.want, c.warnings)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	cases := []struct {
+		limits  limits
+		seconds float64
+		class   string
+		want    []string
+	}{
+		{limits{minDuration: 10 * time.Second}, 4.254, "", []string{"duration 4.254s < 10s"}},
+		{limits{minDuration: 10 * time.Second}, 10, "", []string{}},
+		{limits{maxDuration: time.Minute}, 60.5, "", []string{"duration 60.500s > 1m0s"}},
+		{limits{minClass: "HD"}, 0, "HD-720", []string{"resolution HD-720 < HD"}},
+		{limits{minClass: "HD"}, 0, "DCI-2K", []string{}},
+		{limits{maxClass: "UHD-4K"}, 0, "DCI-4K", []string{"resolution DCI-4K > UHD-4K"}},
+		{limits{minDuration: time.Second, minClass: "UHD-4K"}, 0.5, "HD", []string{"duration 0.500s < 1s", "resolution HD < UHD-4K"}},
+	}
+	for _, c := range cases {
+		got := c.limits.check(c.seconds, c.class)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Fatalf("%+v %v %v: got %q, want %q", c.limits, c.seconds, c.class, got, c.want)
+		}
+	}
+	for s, want := range map[string]string{"HD": "HD", "uhd-4k": "UHD-4K", "3840*2160": "UHD-4K", "1998x1080": "DCI-2K", "": "", "big": ""} {
+		got, err := parseLimitClass(s)
+		if (err != nil) != (want == "") {
+			t.Fatalf("%v: got error %v", s, err)
+		}
+		if got != want {
+			t.Fatalf("%v: got %v, want %v", s, got, want)
+		}
+	}
+	b, err := os.ReadFile("testdata/ffprobe_1.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_1.out")
+	}
+	got, err := parse(string(b), config{limits: limits{minDuration: 10 * time.Second, minClass: "UHD-4K"}})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if want := "duration 4.254s < 10s, resolution HD < UHD-4K"; got.outlier != want {
+		t.Fatalf("got %v, want %v", got.outlier, want)
+	}
+}
+
+func TestSidecar(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.mov")
+	if err := os.WriteFile(file, []byte("data"), 0644); err != nil {
+		t.Fatalf("couldn't write file: %v", err)
+	}
+	fresh, err := sidecarFresh(file)
+	if err != nil || fresh {
+		t.Fatalf("got %v %v, want false without a sidecar", fresh, err)
+	}
+	info := &Info{File: file, Start: "01:00:00:00", Streams: []StreamInfo{{Index: 0, Type: "video"}}}
+	if err := writeSidecar(file, info); err != nil {
+		t.Fatalf("writeSidecar error: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "a.mov.movinfo.json"))
+	if err != nil {
+		t.Fatalf("couldn't read sidecar: %v", err)
+	}
+	fi, err := os.Stat(filepath.Join(dir, "a.mov.movinfo.json"))
+	if err != nil {
+		t.Fatalf("couldn't stat sidecar: %v", err)
+	}
+	if fi.Mode().Perm() != 0644 {
+		t.Fatalf("got mode %v, want -rw-r--r--", fi.Mode())
+	}
+	got := &Info{}
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatalf("invalid sidecar: %v", err)
+	}
+	if !reflect.DeepEqual(got, info) {
+		t.Fatalf("got %+v, want %+v", got, info)
+	}
+	// the keys are the field names of -json.
+	keys := map[string]any{}
+	if err := json.Unmarshal(b, &keys); err != nil {
+		t.Fatalf("invalid sidecar: %v", err)
+	}
+	for _, k := range []string{"file", "start", "pixfmt", "frames_diff", "streams"} {
+		if _, ok := keys[k]; !ok {
+			t.Fatalf("no %v in %s", k, b)
+		}
+	}
+	if !strings.Contains(string(b), `"attached_pic": false`) {
+		t.Fatalf("no attached_pic of the stream in %s", b)
+	}
+	fresh, err = sidecarFresh(file)
+	if err != nil || !fresh {
+		t.Fatalf("got %v %v, want true after writing the sidecar", fresh, err)
+	}
+	// the mov is modified after the sidecar.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatalf("couldn't change time: %v", err)
+	}
+	fresh, err = sidecarFresh(file)
+	if err != nil || fresh {
+		t.Fatalf("got %v %v, want false for a modified mov", fresh, err)
+	}
+	if _, err := sidecarFresh(filepath.Join(dir, "b.mov")); err == nil {
+		t.Fatalf("want error for a missing mov")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("got %v files, want the mov and its sidecar only", len(entries))
+	}
+}
+
+func TestUseColor(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatalf("couldn't create file: %v", err)
+	}
+	defer f.Close()
+	t.Setenv("NO_COLOR", "")
+	// neither a buffer nor a file is a terminal.
+	cases := []struct {
+		mode    string
+		w       io.Writer
+		machine bool
+		want    bool
+	}{
+		{colorAuto, &bytes.Buffer{}, false, false},
+		{colorAuto, nopCloser{f}, false, false},
+		{colorAlways, &bytes.Buffer{}, false, true},
+		{colorAlways, &bytes.Buffer{}, true, false},
+		{colorNever, &bytes.Buffer{}, false, false},
+	}
+	for _, c := range cases {
+		got, err := useColor(c.mode, c.w, c.machine)
+		if err != nil {
+			t.Fatalf("%v: useColor error: %v", c.mode, err)
+		}
+		if got != c.want {
+			t.Fatalf("%v %T %v: got %v, want %v", c.mode, c.w, c.machine, got, c.want)
+		}
+	}
+	if _, err := useColor("yes", &bytes.Buffer{}, false); err == nil {
+		t.Fatalf("want error for unknown color mode")
+	}
+	got := colorFields([]field{{"start", "00:00:00:00"}, {"cover", "none"}, {"drop_check", "skipped frame"}})
+	want := []field{{"start", ansiGreen + "00:00:00:00" + ansiReset}, {"cover", "none"}, {"drop_check", ansiRed + "skipped frame" + ansiReset}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestOpenOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(path, []byte("old results\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := openOutput(path)
+	// the file isn't truncated until a result is written.
+	if b, err := os.ReadFile(path); err != nil || string(b) != "old results\n" {
+		t.Fatalf("got %q %v, want the old results", b, err)
+	}
+	// results of two movs accumulate in the file.
+	printFields(w, []field{{"start", "00:00:00:00"}, {"end", "00:00:04:05"}}, false)
+	printFields(w, []field{{"start", "01:00:00:00"}, {"fps", "23.98"}}, true)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "00:00:00:00\n00:00:04:05\nstart=01:00:00:00 fps=23.98\n"
+	if string(b) != want {
+		t.Fatalf("got %q, want %q", b, want)
+	}
+	if w := openOutput(""); w != (nopCloser{os.Stdout}) {
+		t.Fatalf("got %v, want stdout", w)
+	}
+	// nothing is written, so the file isn't created.
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	if err := openOutput(missing).Close(); err != nil {
+		t.Fatalf("close error: %v", err)
+	}
+	if _, err := os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("got %v, want the file not created", err)
+	}
+	// the error of creating the file is returned by Close too.
+	w = openOutput(filepath.Join(t.TempDir(), "no", "dir", "out.txt"))
+	fmt.Fprintln(w, "00:00:00:00")
+	if err := w.Close(); err == nil {
+		t.Fatalf("got no error, want one")
+	}
+}
+
+func TestParseInvalidSize(t *testing.T) {
+	b, err := os.ReadFile("testdata/ffprobe_32.out")
+	if err != nil {
+		t.Fatalf("couldn't read file: testdata/ffprobe_32.out")
+	}
+	for _, cfg := range []config{{resolution: true}, {class: true}} {
+		_, err := parse(string(b), cfg)
+		if !errors.Is(err, ErrInvalidSize) {
+			t.Fatalf("%+v: got error %v, want ErrInvalidSize", cfg, err)
+		}
+	}
+	cases := []struct {
+		width   string
+		height  string
+		wantErr error
+	}{
+		{"1920", "1080", nil},
+		{"", "1080", ErrMissingWidth},
+		{"1920", "", ErrMissingHeight},
+		{"0", "1080", ErrInvalidSize},
+		{"1920", "-1080", ErrInvalidSize},
+	}
+	for _, c := range cases {
+		_, _, err := parseSize(c.width, c.height)
+		if !errors.Is(err, c.wantErr) {
+			t.Fatalf("%v*%v: got error %v, want %v", c.width, c.height, err, c.wantErr)
+		}
+	}
+}
+
+func TestParseDurationDiff(t *testing.T) {
+	cases := []struct {
+		file string
+		want string
+	}{
+		{"testdata/ffprobe_1.out", "ok\t0.000\tstream 0 audio 4.254\tstream 1 video 4.254"},
+		// audio is 0.25 seconds shorter than video.
+		{"testdata/ffprobe_33.out", "mismatch\t0.250\tstream 1 video 4.254\tstream 0 audio 4.004"},
+	}
+	for _, c := range cases {
+		b, err := os.ReadFile(c.file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %s", c.file)
+		}
+		got, err := parse(string(b), config{durationDiff: true, durationThreshold: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if got.durationDiff != c.want {
+			t.Fatalf("%v: got %q, want %q", c.file, got.durationDiff, c.want)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	g := newGroups("resolution")
+	movs := []struct {
+		file       string
+		resolution string
+	}{
+		{"a.mov", "1920*1080"},
+		{"b.mov", "3840*2160"},
+		{"c.mov", "1920*1080"},
+		{"d.mov", "1280*720"},
+	}
+	for _, m := range movs {
+		flds := []field{{"start", "01:00:00:00"}, {"resolution", m.resolution}}
+		if err := g.add(m.file, flds); err != nil {
+			t.Fatalf("add error: %v", err)
+		}
+	}
+	if err := g.add("e.mov", []field{{"start", "01:00:00:00"}}); err == nil {
+		t.Fatalf("want error for a mov without the field")
+	}
+	b := &bytes.Buffer{}
+	if err := g.print(b, false); err != nil {
+		t.Fatalf("print error: %v", err)
+	}
+	want := "1920*1080 (2)\n\ta.mov\n\tc.mov\n1280*720 (1)\n\td.mov\n3840*2160 (1)\n\tb.mov\n"
+	if b.String() != want {
+		t.Fatalf("got %q, want %q", b.String(), want)
+	}
+	b.Reset()
+	if err := g.print(b, true); err != nil {
+		t.Fatalf("print error: %v", err)
+	}
+	got := map[string][]string{}
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json %v: %v", b.String(), err)
+	}
+	if !reflect.DeepEqual(got, g.files) {
+		t.Fatalf("got %v, want %v", got, g.files)
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	cfg := config{start: true, end: true, duration: true, fps: true, resolution: true, codec: true, colorspace: true}
+	results := []result{}
+	for _, file := range []string{"testdata/ffprobe_1.out", "testdata/ffprobe_9.out"} {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("couldn't read file: %v", file)
+		}
+		res, err := parse(string(b), cfg)
+		if err != nil {
+			t.Fatalf("%v: parse error: %v", file, err)
+		}
+		results = append(results, res)
+	}
+	diffs := diffResults(results[0], results[0], nil)
+	for _, d := range diffs {
+		if d.differs() {
+			t.Fatalf("got %v differs, want the same mov to be the same", d.name)
+		}
+	}
+	if len(diffs) != 7 {
+		t.Fatalf("got %v fields, want 7", len(diffs))
+	}
+	diffs = diffResults(results[0], results[1], []string{"start", "end", "duration", "codec"})
+	w := &bytes.Buffer{}
+	if err := printDiff(w, diffs, false, false); err != nil {
+		t.Fatalf("printDiff error: %v", err)
+	}
+	want := "-fps: 23.98\n+fps: 25\n-resolution: 1920*1080\n+resolution: 2048*1080\n colorspace: bt709\n"
+	if w.String() != want {
+		t.Fatalf("got %q, want %q", w.String(), want)
+	}
+	w.Reset()
+	if err := printDiff(w, diffs, true, false); err != nil {
+		t.Fatalf("printDiff error: %v", err)
+	}
+	if want := `{"fps":["23.98","25"],"resolution":["1920*1080","2048*1080"]}` + "\n"; w.String() != want {
+		t.Fatalf("got %q, want %q", w.String(), want)
+	}
+	// a field only one of them has.
+	w.Reset()
+	if err := printDiff(w, diffResults(result{cover: "none"}, result{}, nil), false, false); err != nil {
+		t.Fatalf("printDiff error: %v", err)
+	}
+	if want := "-cover: none\n+cover: (missing)\n"; w.String() != want {
+		t.Fatalf("got %q, want %q", w.String(), want)
+	}
+}
+
+// TestFakeFFprobe isn't a test. It is ffprobe for the tests that run the test binary
+// for it, which behaves as MOVINFO_FAKE_FFPROBE says. Every run appends its arguments
+// to the file of MOVINFO_FAKE_FFPROBE_RUNS.
+func TestFakeFFprobe(t *testing.T) {
+	mode := os.Getenv("MOVINFO_FAKE_FFPROBE")
+	if mode == "" {
+		return
+	}
+	args := os.Args
+	for i, a := range args {
+		if a == "--" {
+			args = args[i+1:]
+			break
+		}
+	}
+	f, err := os.OpenFile(os.Getenv("MOVINFO_FAKE_FFPROBE_RUNS"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		os.Exit(2)
+	}
+	fmt.Fprintln(f, strings.Join(args, " "))
+	f.Close()
+	file := args[len(args)-1]
+	switch mode {
+	case "open":
+		fmt.Fprintf(os.Stderr, "%v: Input/output error\n", file)
+	case "corrupt":
+		fmt.Fprintf(os.Stderr, "%v: Invalid data found when processing input\n", file)
+	case "entries":
+		// an old ffprobe that doesn't know an entry, but -show_streams works.
+		if strings.Contains(strings.Join(args, " "), "-show_entries") {
+			fmt.Fprintln(os.Stderr, "Failed to set value 'stream=index' for option 'show_entries': Invalid argument")
+			break
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			break
+		}
+		os.Stdout.Write(b)
+		os.Exit(0)
+	}
+	os.Exit(1)
+}
+
+// fakeFFprobe makes cfg run the test binary as ffprobe in the mode of TestFakeFFprobe,
+// and returns a func that returns the arguments of the runs so far.
+func fakeFFprobe(t *testing.T, cfg *config, mode string) func() []string {
+	runs := filepath.Join(t.TempDir(), "runs")
+	t.Setenv("MOVINFO_FAKE_FFPROBE", mode)
+	t.Setenv("MOVINFO_FAKE_FFPROBE_RUNS", runs)
+	cfg.ffprobe = os.Args[0]
+	cfg.ffprobeArgs = []string{"-test.run=^TestFakeFFprobe$", "--"}
+	return func() []string {
+		b, _ := os.ReadFile(runs)
+		return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	}
+}
+
+func TestProbeFileRetries(t *testing.T) {
+	cases := []struct {
+		mode string
+		cfg  config
+		// want is the probe of each run.
+		want    []string
+		wantErr bool
+	}{
+		// only the first probe is retried, the fallback runs once.
+		{"open", config{end: true, retries: 2}, []string{"-show_entries", "-show_entries", "-show_entries", "-show_streams"}, true},
+		{"open", config{start: true, retries: 1}, []string{"stream_disposition", "stream_disposition", "-show_entries", "-show_streams"}, true},
+		// a corrupt mov isn't retried.
+		{"corrupt", config{end: true, retries: 2}, []string{"-show_entries", "-show_streams"}, true},
+		// -show_streams works when -show_entries fails.
+		{"entries", config{end: true, retries: 2}, []string{"-show_entries", "-show_streams"}, false},
+	}
+	for _, c := range cases {
+		runs := fakeFFprobe(t, &c.cfg, c.mode)
+		res, err := probeFile("testdata/ffprobe_1.out", c.cfg)
+		if (err != nil) != c.wantErr {
+			t.Fatalf("%v, retries %v: got error %v, want error %v", c.mode, c.cfg.retries, err, c.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrProbe) {
+			t.Fatalf("%v, retries %v: got %v, want %v", c.mode, c.cfg.retries, err, ErrProbe)
+		}
+		if !c.wantErr && res.end != "00:00:04:05" {
+			t.Fatalf("%v, retries %v: got end %v, want 00:00:04:05", c.mode, c.cfg.retries, res.end)
+		}
+		got := runs()
+		if len(got) != len(c.want) {
+			t.Fatalf("%v, retries %v: got runs %q, want %q", c.mode, c.cfg.retries, got, c.want)
+		}
+		for i := range got {
+			if !strings.Contains(got[i], c.want[i]) {
+				t.Fatalf("%v, retries %v: got runs %q, want %q", c.mode, c.cfg.retries, got, c.want)
+			}
+		}
+	}
+	// the retries give up at the deadline, instead of waiting for the backoff.
+	cfg := config{end: true, retries: 10, timeout: 300 * time.Millisecond}
+	runs := fakeFFprobe(t, &cfg, "open")
+	start := time.Now()
+	if _, err := probeFile("testdata/ffprobe_1.out", cfg); !errors.Is(err, ErrProbe) {
+		t.Fatalf("got %v, want %v", err, ErrProbe)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("gave up after %v, want about %v", d, cfg.timeout)
+	}
+	if n := len(runs()); n > 3 {
+		t.Fatalf("got %v runs, want at most 3 in %v", n, cfg.timeout)
+	}
+}
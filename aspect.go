@@ -0,0 +1,41 @@
+package movinfo
+
+import "fmt"
+
+// anamorphicSize is a coded size that is only used with non-square pixels,
+// and the sample aspect ratio it is displayed in.
+type anamorphicSize struct {
+	width  int
+	height int
+	sar    string
+	// format is what writes the size. (ex. HDV)
+	format string
+}
+
+// anamorphicSizes are coded sizes of HD formats that squeeze the width.
+// SD sizes aren't, as 720*480 and the others are also used with square pixels.
+var anamorphicSizes = []anamorphicSize{
+	{1440, 1080, "4:3", "HDV, XDCAM HD"},
+	{1280, 1080, "3:2", "DVCPRO HD"},
+	{960, 720, "4:3", "DVCPRO HD"},
+}
+
+// checkAspect checks a video of an anamorphic coded size has non-square pixels.
+// Unknown sample aspect ratio (0:1) is also displayed as square, so it is the same.
+// It returns the problem, or "" when there isn't.
+func checkAspect(width, height int, sar string) string {
+	switch sar {
+	case "1:1":
+	case "", "0:1":
+		sar = "unknown"
+	default:
+		return ""
+	}
+	for _, s := range anamorphicSizes {
+		if s.width == width && s.height == height {
+			dw, dh := displaySize(width, height, s.sar, 0)
+			return fmt.Sprintf("%v*%v is anamorphic (%v, sar %v) but sar is %v, the display may be squished as %v*%v instead of %v*%v", width, height, s.format, s.sar, sar, width, height, dw, dh)
+		}
+	}
+	return ""
+}
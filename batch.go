@@ -0,0 +1,56 @@
+package movinfo
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// readFileList reads paths of -from-file, one path per line. Empty lines are skipped,
+// and so is \r of CRLF line endings. Other spaces are kept, as they could be in a path.
+func readFileList(r io.Reader) ([]string, error) {
+	files := []string{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		file := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.TrimSpace(file) == "" {
+			continue
+		}
+		files = append(files, file)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// loadFileList reads the list of paths in the file, or stdin when it is -.
+func loadFileList(path string) ([]string, error) {
+	if path == "-" {
+		return readFileList(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readFileList(f)
+}
+
+// runBatch calls do for each file in order, and returns the files it failed for.
+// fail is called with the error of each failed file. With failFast, it stops
+// at the first failure, otherwise it goes through all the files.
+func runBatch(files []string, failFast bool, do func(file string) error, fail func(file string, err error)) []string {
+	failed := []string{}
+	for _, file := range files {
+		if err := do(file); err != nil {
+			fail(file, err)
+			failed = append(failed, file)
+			if failFast {
+				break
+			}
+		}
+	}
+	return failed
+}
@@ -0,0 +1,94 @@
+package movinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// bitrateArgs are ffprobe arguments to show time and size of every video packet,
+// one packet per line. (ex. 0.041708,912345)
+var bitrateArgs = []string{"-select_streams", "v:0", "-show_entries", "packet=pts_time,size", "-of", "csv=p=0"}
+
+// bitrateIntervals returns value for ffprobe's -read_intervals option,
+// that reads the first second of each period of every seconds, in a mov of length seconds.
+func bitrateIntervals(length float64, every int) string {
+	intervals := []string{}
+	for t := 0; float64(t) < length; t += every {
+		intervals = append(intervals, fmt.Sprintf("%v%%+1", t))
+	}
+	return strings.Join(intervals, ",")
+}
+
+// probeBitrate runs ffprobe for packets of the video, and returns its peak and average bitrate.
+// Packets are read as ffprobe prints them, so a long mov doesn't fill up the memory.
+// When every is bigger than 1, only a second in every seconds is read.
+func probeBitrate(ctx context.Context, cmd []string, file string, length float64, every int) (string, error) {
+	args := append([]string{}, bitrateArgs...)
+	if every > 1 && length > 0 {
+		args = append(args, "-read_intervals", bitrateIntervals(length, every))
+	}
+	var peak, avg float64
+	err := probeStream(ctx, cmd, file, args, func(r io.Reader) error {
+		var err error
+		peak, avg, err = bitrateStats(r, length)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("peak %.2f Mb/s, average %.2f Mb/s", peak/1e6, avg/1e6), nil
+}
+
+// bitrateStats reads packets of bitrateArgs format, and returns peak and average bitrate
+// in bits per second. Peak is the biggest sum of packets in a second.
+// length is duration of the mov in seconds, so the last second is counted only
+// for its part. It is ignored when it is 0.
+func bitrateStats(r io.Reader, length float64) (peak, avg float64, err error) {
+	seconds := map[int]float64{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		t, size, ok := strings.Cut(strings.TrimSpace(sc.Text()), ",")
+		if !ok || t == "N/A" {
+			continue
+		}
+		pts, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid packet time: %v", t)
+		}
+		n, err := strconv.Atoi(size)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid packet size: %v", size)
+		}
+		seconds[int(math.Floor(pts))] += float64(n) * 8
+	}
+	if err := sc.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(seconds) == 0 {
+		return 0, 0, fmt.Errorf("no video packets")
+	}
+	total := 0.0
+	span := 0.0
+	for s, bits := range seconds {
+		total += bits
+		d := 1.0
+		if length > float64(s) && length-float64(s) < 1 {
+			d = length - float64(s)
+		}
+		span += d
+		// a partial second isn't a peak, even if it is dense.
+		if d == 1 && bits > peak {
+			peak = bits
+		}
+	}
+	if peak == 0 {
+		// shorter than a second.
+		peak = total / span
+	}
+	return peak, total / span, nil
+}
@@ -0,0 +1,8 @@
+// Command movinfo prints timecode and other information of movs with ffprobe.
+package main
+
+import "github.com/kzmdstu/movinfo"
+
+func main() {
+	movinfo.Main()
+}
@@ -0,0 +1,83 @@
+package movinfo
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// color modes for -color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// ANSI escape codes for colorFields.
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// useColor reports whether results written to w should be colored in the mode.
+// machine is true for json and compact output, that are never colored.
+// auto colors only when w is a terminal, and NO_COLOR isn't set.
+func useColor(mode string, w io.Writer, machine bool) (bool, error) {
+	switch mode {
+	case colorNever:
+		return false, nil
+	case colorAlways:
+		return !machine, nil
+	case colorAuto:
+		return !machine && os.Getenv("NO_COLOR") == "" && isTerminal(w), nil
+	}
+	return false, fmt.Errorf("unknown color mode: %v", mode)
+}
+
+// isTerminal reports whether w is a terminal, rather than a file or a pipe.
+func isTerminal(w io.Writer) bool {
+	if nc, ok := w.(nopCloser); ok {
+		w = nc.Writer
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// colorFields colors values of the fields, red for problems and green for the others.
+// Values that tell the mov doesn't have something, like none, aren't colored
+// as it isn't a problem.
+func colorFields(flds []field) []field {
+	colored := make([]field, 0, len(flds))
+	for _, f := range flds {
+		switch {
+		case f.value == "none" || f.value == "":
+		case isProblem(f):
+			f.value = ansiRed + f.value + ansiReset
+		default:
+			f.value = ansiGreen + f.value + ansiReset
+		}
+		colored = append(colored, f)
+	}
+	return colored
+}
+
+// isProblem reports whether the field tells a problem of the mov,
+// like a mismatch of a check.
+func isProblem(f field) bool {
+	switch f.name {
+	case "drop_check", "timecode_rate_check", "end_check", "standard", "aspect_check":
+		return f.value != "ok"
+	case "duration_diff":
+		return strings.HasPrefix(f.value, "mismatch")
+	}
+	return f.value == loudnessUnavailable
+}
@@ -0,0 +1,57 @@
+package movinfo
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// contentHashArgs are ffprobe arguments to show SHA-256 of the data of the first n
+// video packets, one packet per line. (ex. SHA256:9f86d0...) Packets are read without decoding them.
+func contentHashArgs(n int) []string {
+	return []string{"-select_streams", "v:0", "-read_intervals", "%+#" + strconv.Itoa(n), "-show_data_hash", "sha256", "-show_entries", "packet=data_hash", "-of", "csv=p=0"}
+}
+
+// probeContentHash runs ffprobe for hashes of the first n video packets, and returns
+// the content hash of them.
+func probeContentHash(ctx context.Context, cmd []string, file string, n int) (string, error) {
+	var hash string
+	err := probeStream(ctx, cmd, file, contentHashArgs(n), func(r io.Reader) error {
+		var err error
+		hash, err = readContentHash(r)
+		return err
+	})
+	return hash, err
+}
+
+// readContentHash reads packet hashes of contentHashArgs format, and returns SHA-256
+// of them in order, as hex. Only the data of the packets is hashed, not their times
+// or the container, so a mov re-wrapped without re-encoding has the same hash.
+func readContentHash(r io.Reader) (string, error) {
+	h := sha256.New()
+	packets := 0
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" {
+			continue
+		}
+		if !strings.HasPrefix(l, "SHA256:") {
+			return "", fmt.Errorf("invalid packet hash: %v", l)
+		}
+		fmt.Fprintln(h, l)
+		packets++
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	if packets == 0 {
+		return "", fmt.Errorf("no video packets")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,71 @@
+package movinfo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// creationLayouts are layouts of creation_time that muxers write. Fractional seconds
+// are accepted without being in the layouts. Ones without a zone are read as UTC.
+var creationLayouts = []string{
+	time.RFC3339,
+	// com.apple.quicktime.creationdate style. (ex. 2022-07-01T17:24:37+0900)
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// parseCreationTime parses creation_time of the format or a stream.
+func parseCreationTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range creationLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid creation_time: %v", s)
+}
+
+// utcOffset is a fixed zone of -timezone. (ex. +09:00)
+var utcOffset = regexp.MustCompile(`^([+-])(\d\d):(\d\d)$`)
+
+// loadZone loads the zone of -timezone. It is UTC, Local, an IANA name (ex. Asia/Tokyo)
+// or an offset from UTC. Empty is UTC.
+func loadZone(name string) (*time.Location, error) {
+	if m := utcOffset.FindStringSubmatch(name); m != nil {
+		h := int(m[2][0]-'0')*10 + int(m[2][1]-'0')
+		min := int(m[3][0]-'0')*10 + int(m[3][1]-'0')
+		if h > 14 || min >= 60 {
+			return nil, fmt.Errorf("invalid timezone: %v", name)
+		}
+		off := (h*60 + min) * 60
+		if m[1] == "-" {
+			off = -off
+		}
+		return time.FixedZone(name, off), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone: %v", name)
+	}
+	return loc, nil
+}
+
+// formatCreationTime formats creation_time in the zone with the layout of Go's time package.
+// It is RFC 3339 when layout is empty.
+func formatCreationTime(s, zone, layout string) (string, error) {
+	t, err := parseCreationTime(s)
+	if err != nil {
+		return "", err
+	}
+	loc, err := loadZone(zone)
+	if err != nil {
+		return "", err
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return t.In(loc).Format(layout), nil
+}
@@ -0,0 +1,25 @@
+package movinfo
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// defaultsEnv is the environment variable that has default flags of movinfo.
+// (ex. MOVINFO_DEFAULTS="-start -end -resolution")
+const defaultsEnv = "MOVINFO_DEFAULTS"
+
+// parseFlags parses the default flags from defaults, then the args.
+// Flags in args override the defaults, as the later one wins.
+// Bool flags could be turned off with =false. (ex. -start=false)
+func parseFlags(fs *flag.FlagSet, defaults string, args []string) error {
+	envArgs := strings.Fields(defaults)
+	for _, a := range envArgs {
+		// a value in the defaults would stop parsing the flags after it.
+		if !strings.HasPrefix(a, "-") {
+			return fmt.Errorf("%v should only have flags, use -flag=value for values: %v", defaultsEnv, a)
+		}
+	}
+	return fs.Parse(append(envArgs, args...))
+}
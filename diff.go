@@ -0,0 +1,113 @@
+package movinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// diffFields are the fields -diff compares when no field is requested.
+var diffFields = []func(c *config){
+	func(c *config) { c.start = true },
+	func(c *config) { c.end = true },
+	func(c *config) { c.duration = true },
+	func(c *config) { c.fps = true },
+	func(c *config) { c.resolution = true },
+	func(c *config) { c.codec = true },
+	func(c *config) { c.colorspace = true },
+}
+
+// fieldDiff is a field of two movs. a or b is empty when the mov doesn't have it.
+type fieldDiff struct {
+	name string
+	a    string
+	b    string
+}
+
+// differs reports whether the movs have different values of the field.
+func (d fieldDiff) differs() bool {
+	return d.a != d.b
+}
+
+// diffMovs probes the two files for the fields of cfg, or diffFields when no
+// field is requested, and compares them.
+func diffMovs(a, b string, cfg config, ignore []string) ([]fieldDiff, error) {
+	if !cfg.requested() {
+		for _, set := range diffFields {
+			set(&cfg)
+		}
+	}
+	resA, err := probeFile(a, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", a, err)
+	}
+	warn(resA.warnings)
+	resB, err := probeFile(b, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", b, err)
+	}
+	warn(resB.warnings)
+	return diffResults(resA, resB, ignore), nil
+}
+
+// diffResults compares the fields of two results in the output order.
+// Fields that neither has, and the ignored fields are skipped.
+func diffResults(a, b result, ignore []string) []fieldDiff {
+	skip := map[string]bool{}
+	for _, name := range ignore {
+		skip[name] = true
+	}
+	fa := a.allFields()
+	fb := b.allFields()
+	diffs := []fieldDiff{}
+	for i := range fa {
+		d := fieldDiff{name: fa[i].name, a: fa[i].value, b: fb[i].value}
+		if skip[d.name] || d.a == "" && d.b == "" {
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// printDiff prints the fields like a unified diff, the same fields with a space
+// and different ones with - for the first mov and + for the second.
+// (ex. -fps: 23.98) A missing value is printed as (missing), as none is a value of some fields.
+// With asJSON, it is an object of the different fields, with values of the movs.
+func printDiff(w io.Writer, diffs []fieldDiff, asJSON, color bool) error {
+	if asJSON {
+		m := map[string][2]string{}
+		for _, d := range diffs {
+			if d.differs() {
+				m[d.name] = [2]string{d.a, d.b}
+			}
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+		return nil
+	}
+	missing := func(v string) string {
+		if v == "" {
+			return "(missing)"
+		}
+		return v
+	}
+	for _, d := range diffs {
+		if !d.differs() {
+			fmt.Fprintf(w, " %v: %v\n", d.name, d.a)
+			continue
+		}
+		a := fmt.Sprintf("-%v: %v", d.name, missing(d.a))
+		b := fmt.Sprintf("+%v: %v", d.name, missing(d.b))
+		if color {
+			a = ansiRed + a + ansiReset
+			b = ansiGreen + b + ansiReset
+		}
+		fmt.Fprintln(w, a)
+		fmt.Fprintln(w, b)
+	}
+	return nil
+}
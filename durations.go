@@ -0,0 +1,77 @@
+package movinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// streamDuration is duration of an audio or video stream.
+type streamDuration struct {
+	index     int
+	codecType string
+	seconds   float64
+}
+
+// parseStreamDurations returns durations of the audio and video streams.
+// Attached pictures and streams without duration are skipped.
+func parseStreamDurations(streams []string) []streamDuration {
+	durations := []streamDuration{}
+	for i, stream := range streams {
+		codecType := ""
+		duration := ""
+		pic := false
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if strings.HasPrefix(l, "codec_type=") {
+				codecType = strings.TrimPrefix(l, "codec_type=")
+			}
+			if strings.HasPrefix(l, "duration=") {
+				duration = dotDecimal(strings.TrimPrefix(l, "duration="))
+			}
+			if l == "DISPOSITION:attached_pic=1" {
+				pic = true
+			}
+		}
+		if (codecType != "video" && codecType != "audio") || pic {
+			continue
+		}
+		d, err := strconv.ParseFloat(duration, 64)
+		if err != nil {
+			continue
+		}
+		durations = append(durations, streamDuration{i, codecType, d})
+	}
+	return durations
+}
+
+// durationDiff reports the biggest difference between durations of the streams,
+// and whether it is over the threshold, like
+//
+//	mismatch	0.250	stream 1 video 4.254	stream 0 audio 4.004
+//
+// with the longest and the shortest stream. It fails when there are less than two streams.
+func durationDiff(durations []streamDuration, threshold time.Duration) (string, error) {
+	if len(durations) < 2 {
+		return "", fmt.Errorf("need two or more audio and video streams with duration, got %v", len(durations))
+	}
+	longest := durations[0]
+	shortest := durations[0]
+	for _, d := range durations[1:] {
+		if d.seconds > longest.seconds {
+			longest = d
+		}
+		if d.seconds <= shortest.seconds {
+			shortest = d
+		}
+	}
+	diff := longest.seconds - shortest.seconds
+	kind := "ok"
+	if diff > threshold.Seconds() {
+		kind = "mismatch"
+	}
+	return fmt.Sprintf("%v\t%v\tstream %v %v %v\tstream %v %v %v", kind, formatSeconds(diff),
+		longest.index, longest.codecType, formatSeconds(longest.seconds),
+		shortest.index, shortest.codecType, formatSeconds(shortest.seconds)), nil
+}
@@ -0,0 +1,99 @@
+package movinfo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kzmdstu/movinfo/timecode"
+)
+
+var (
+	ErrNoStream         = errors.New("cannot find [STREAM] lines")
+	ErrEmptyOutput      = errors.New("ffprobe produced no stream data; file may be corrupt or unsupported")
+	ErrTruncated        = errors.New("ffprobe output truncated")
+	ErrStreamLine       = errors.New("unexpected stream line")
+	ErrNoVideoStream    = errors.New("not found video stream")
+	ErrUnmatchedStream  = errors.New("unmatched video stream")
+	ErrInvalidFrames    = errors.New("invalid frames")
+	ErrInvalidTimecode  = timecode.ErrInvalidTimecode
+	ErrMissingTimecode  = errors.New("missing TAG:timecode information")
+	ErrMissingFPS       = errors.New("missing fps information")
+	ErrMissingFrames    = errors.New("missing nb_frames information")
+	ErrNoFrames         = errors.New("video stream has no frames")
+	ErrMissingWidth     = errors.New("missing width information")
+	ErrMissingHeight    = errors.New("missing height information")
+	ErrInvalidSize      = errors.New("invalid width or height")
+	ErrUnsupportedFPS   = errors.New("unsupported fps")
+	ErrUnknownBase      = timecode.ErrUnknownBase
+	ErrTimecodeRange    = timecode.ErrTimecodeRange
+	ErrUnknownPixFmt    = errors.New("unknown pixel format")
+	ErrMissingFramerate = errors.New("need -framerate for an image sequence")
+	ErrNoKeyframe       = errors.New("no keyframe before the target")
+	ErrNoFlag           = errors.New("need to set at least one flag")
+	ErrProbe            = errors.New("failed to execute")
+)
+
+// errorCodes maps sentinel errors to the codes reported in -json mode.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrNoStream, "ErrNoStream"},
+	{ErrEmptyOutput, "ErrEmptyOutput"},
+	{ErrTruncated, "ErrTruncated"},
+	{ErrStreamLine, "ErrStreamLine"},
+	{ErrNoVideoStream, "ErrNoVideoStream"},
+	{ErrUnmatchedStream, "ErrUnmatchedStream"},
+	{ErrInvalidFrames, "ErrInvalidFrames"},
+	{ErrInvalidTimecode, "ErrInvalidTimecode"},
+	{ErrMissingTimecode, "ErrMissingTimecode"},
+	{ErrMissingFPS, "ErrMissingFPS"},
+	{ErrMissingFrames, "ErrMissingFrames"},
+	{ErrNoFrames, "ErrNoFrames"},
+	{ErrMissingWidth, "ErrMissingWidth"},
+	{ErrMissingHeight, "ErrMissingHeight"},
+	{ErrInvalidSize, "ErrInvalidSize"},
+	{ErrUnsupportedFPS, "ErrUnsupportedFPS"},
+	{ErrUnknownBase, "ErrUnknownBase"},
+	{ErrTimecodeRange, "ErrTimecodeRange"},
+	{ErrUnknownPixFmt, "ErrUnknownPixFmt"},
+	{ErrMissingFramerate, "ErrMissingFramerate"},
+	{ErrNoKeyframe, "ErrNoKeyframe"},
+	{ErrNoFlag, "ErrNoFlag"},
+	{ErrProbe, "ErrProbe"},
+}
+
+// errorCode returns the code of the sentinel error wrapped by err.
+// It returns "ErrUnknown" when err doesn't wrap any of them.
+func errorCode(err error) string {
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return "ErrUnknown"
+}
+
+// jsonError is the form of an error written to stderr in -json mode.
+type jsonError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// writeJSONError writes err as a jsonError to stderr.
+func writeJSONError(file string, err error) {
+	b, merr := json.Marshal(jsonError{
+		File:  file,
+		Error: err.Error(),
+		Code:  errorCode(err),
+	})
+	if merr != nil {
+		// shouldn't happen, all the fields are strings.
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, string(b))
+}
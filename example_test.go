@@ -0,0 +1,19 @@
+package movinfo_test
+
+import (
+	"fmt"
+
+	"github.com/kzmdstu/movinfo"
+)
+
+// ProbeAll needs ffprobe, so the example isn't run.
+func ExampleProbeAll() {
+	info, err := movinfo.ProbeAll("a.mov")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(info.Start, info.End, info.FPS)
+	for _, s := range info.Streams {
+		fmt.Println(s.Index, s.Type, s.Codec)
+	}
+}
@@ -0,0 +1,104 @@
+package movinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// fragmentArgs are ffprobe arguments to log the boxes the mov demuxer reads, with the format name.
+// The boxes are only logged at trace level. (ex. [mov,mp4,m4a,3gp,3g2,mj2 @ 0x...] type:'moov' parent:'root' sz: ...)
+var fragmentArgs = []string{"-v", "trace", "-show_entries", "format=format_name"}
+
+// probeFragmented runs ffprobe with fragmentArgs for the file, and reads its trace while it runs.
+// ffprobe is stopped at the first box of movie fragments, as the rest of the trace is
+// mostly of the samples, that can be much bigger than the mov. raw dumps what was read.
+func probeFragmented(ctx context.Context, cmd []string, file string, raw bool) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	defer pr.Close()
+	c := ffprobeCommand(ctx, cmd, file, fragmentArgs)
+	// the trace is logged to stderr, and format_name is printed to stdout.
+	c.Stdout = pw
+	c.Stderr = pw
+	err = c.Start()
+	pw.Close()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrProbe, err)
+	}
+	tail := &tailBuffer{}
+	r := io.TeeReader(pr, tail)
+	if raw {
+		r = io.TeeReader(r, os.Stderr)
+	}
+	res, perr := parseFragmented(r)
+	if perr == nil && res == "fragmented" {
+		cancel()
+		c.Wait()
+		return res, nil
+	}
+	// ffprobe blocks on writing, if parseFragmented stopped early.
+	io.Copy(io.Discard, r)
+	if err := c.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%w: %v", ErrProbe, ctx.Err())
+		}
+		return "", fmt.Errorf("%w: %s", ErrProbe, tail.lastLine())
+	}
+	return res, perr
+}
+
+// tailBuffer keeps the last bytes written to it, for the error at the end of a long log.
+type tailBuffer struct {
+	b []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.b = append(t.b, p...)
+	if n := len(t.b) - 4096; n > 0 {
+		t.b = append([]byte{}, t.b[n:]...)
+	}
+	return len(p), nil
+}
+
+// lastLine returns the last non empty line in the buffer.
+func (t *tailBuffer) lastLine() string {
+	s := strings.TrimRight(string(t.b), "\n")
+	return s[strings.LastIndex(s, "\n")+1:]
+}
+
+// parseFragmented parses output of fragmentArgs, and returns whether the mov is fragmented,
+// that is it has mvex box in moov for movie fragments, or moof box, or progressive with all
+// the samples in moov. Fragmented movs are ready for DASH and HLS packaging.
+// It returns "not mp4" and the format for other containers.
+// It stops reading at the first mvex or moof box, which only the mov demuxer logs.
+func parseFragmented(r io.Reader) (string, error) {
+	format := ""
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := sc.Text()
+		if strings.HasPrefix(l, "format_name=") {
+			format = strings.TrimPrefix(l, "format_name=")
+		}
+		if strings.Contains(l, "type:'mvex' parent:'moov'") || strings.Contains(l, "type:'moof' parent:'root'") {
+			return "fragmented", nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	if format == "" {
+		return "", fmt.Errorf("%w: no format_name", ErrProbe)
+	}
+	if !strings.Contains(format, "mp4") {
+		return fmt.Sprintf("not mp4 (%v)", format), nil
+	}
+	return "progressive", nil
+}
@@ -0,0 +1,181 @@
+package movinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// gopArgs are ffprobe arguments to show picture type of every video frame,
+// one frame per line. (ex. I)
+var gopArgs = []string{"-select_streams", "v:0", "-show_entries", "frame=pict_type", "-of", "csv=p=0"}
+
+// gopStats is counts of frames by their picture type.
+type gopStats struct {
+	i int
+	p int
+	b int
+	// other is frames of other types, like S for sprite.
+	other int
+}
+
+// String formats the gopStats for -gop. (ex. I 10, P 40, B 150, I-frame ratio 5.0%)
+func (g gopStats) String() string {
+	total := g.i + g.p + g.b + g.other
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(g.i) / float64(total) * 100
+	}
+	s := fmt.Sprintf("I %v, P %v, B %v", g.i, g.p, g.b)
+	if g.other > 0 {
+		s += fmt.Sprintf(", other %v", g.other)
+	}
+	return s + fmt.Sprintf(", I-frame ratio %.1f%%", ratio)
+}
+
+// probeGOP runs ffprobe for picture types of the video frames, and returns their counts.
+// It decodes all the frames, so it is slow for long movs.
+func probeGOP(ctx context.Context, cmd []string, file string) (string, error) {
+	var g gopStats
+	err := probeStream(ctx, cmd, file, gopArgs, func(r io.Reader) error {
+		var err error
+		g, err = countPictTypes(r)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return g.String(), nil
+}
+
+// countPictTypes reads picture types of gopArgs format, and counts them.
+func countPictTypes(r io.Reader) (gopStats, error) {
+	g := gopStats{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		// newer ffprobe could add a comma for side data.
+		t, _, _ := strings.Cut(strings.TrimSpace(sc.Text()), ",")
+		switch t {
+		case "":
+		case "I":
+			g.i++
+		case "P":
+			g.p++
+		case "B":
+			g.b++
+		default:
+			g.other++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return g, err
+	}
+	if g.i+g.p+g.b+g.other == 0 {
+		return g, fmt.Errorf("no video frames")
+	}
+	return g, nil
+}
+
+// gopStructureArgs are ffprobe arguments to show key flag and picture type of every
+// video frame in display order, one frame per line. (ex. key_frame=1|pict_type=I)
+var gopStructureArgs = []string{"-select_streams", "v:0", "-show_entries", "frame=key_frame,pict_type", "-of", "compact=p=0"}
+
+// gopFrame is a video frame of -gop-structure.
+type gopFrame struct {
+	key  bool
+	pict string
+}
+
+// probeGOPStructure runs ffprobe for key flags and picture types of the video frames,
+// and returns whether the GOPs are open or closed, with their length and scene cuts.
+// It decodes all the frames, so it is slow for long movs.
+func probeGOPStructure(ctx context.Context, cmd []string, file string) (string, error) {
+	var frames []gopFrame
+	err := probeStream(ctx, cmd, file, gopStructureArgs, func(r io.Reader) error {
+		var err error
+		frames, err = readGOPFrames(r)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return gopStructure(frames), nil
+}
+
+// readGOPFrames reads frames of gopStructureArgs format.
+func readGOPFrames(r io.Reader) ([]gopFrame, error) {
+	frames := []gopFrame{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" {
+			continue
+		}
+		f := gopFrame{}
+		for _, kv := range strings.Split(l, "|") {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "key_frame":
+				f.key = v == "1"
+			case "pict_type":
+				f.pict = v
+			}
+		}
+		frames = append(frames, f)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no video frames")
+	}
+	return frames, nil
+}
+
+// gopStructure tells whether GOPs of the frames are open or closed, and estimates
+// scene cuts. (ex. closed, GOP 24 frames, 3 scene cuts)
+//
+// A GOP is open when B frames just before its I frame are shown, as they refer to
+// the I frame of the next GOP. Encoders put an extra I frame at a scene cut,
+// so GOPs shorter than the usual length are counted as scene cuts. It is only
+// an estimate, and is 0 for encoders with a fixed GOP.
+func gopStructure(frames []gopFrame) string {
+	open := false
+	starts := []int{}
+	for i, f := range frames {
+		if !f.key && f.pict != "I" {
+			continue
+		}
+		starts = append(starts, i)
+		if i > 0 && frames[i-1].pict == "B" {
+			open = true
+		}
+	}
+	s := "closed"
+	if open {
+		s = "open"
+	}
+	if len(starts) < 2 {
+		// only one GOP, of all the frames.
+		return s + fmt.Sprintf(", GOP %v frames, 0 scene cuts", len(frames))
+	}
+	// the usual length is the most common one.
+	count := map[int]int{}
+	length := 0
+	for i := 1; i < len(starts); i++ {
+		n := starts[i] - starts[i-1]
+		count[n]++
+		if count[n] > count[length] || count[n] == count[length] && n > length {
+			length = n
+		}
+	}
+	cuts := 0
+	for i := 1; i < len(starts); i++ {
+		if starts[i]-starts[i-1] < length {
+			cuts++
+		}
+	}
+	return s + fmt.Sprintf(", GOP %v frames, %v scene cuts", length, cuts)
+}
@@ -0,0 +1,67 @@
+package movinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// groups are files bucketed by their value of a field, for -group-by.
+type groups struct {
+	field string
+	files map[string][]string
+}
+
+func newGroups(field string) *groups {
+	return &groups{field: field, files: map[string][]string{}}
+}
+
+// add puts the file in the group of its value of the field.
+// It fails when the field isn't one of the fields, as it isn't requested.
+func (g *groups) add(file string, flds []field) error {
+	for _, f := range flds {
+		if f.name == g.field {
+			g.files[f.value] = append(g.files[f.value], file)
+			return nil
+		}
+	}
+	return fmt.Errorf("-group-by %v: the mov doesn't have the field, it should also be requested", g.field)
+}
+
+// values returns the values of the groups, from the biggest group.
+// Groups of the same size are in order of their values.
+func (g *groups) values() []string {
+	values := make([]string, 0, len(g.files))
+	for v := range g.files {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i], values[j]
+		if len(g.files[a]) != len(g.files[b]) {
+			return len(g.files[a]) > len(g.files[b])
+		}
+		return a < b
+	})
+	return values
+}
+
+// print prints each group as its value and number of files, followed by
+// the files indented with a tab. With asJSON, it is an object of the files by the values.
+func (g *groups) print(w io.Writer, asJSON bool) error {
+	if asJSON {
+		b, err := json.Marshal(g.files)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+		return nil
+	}
+	for _, v := range g.values() {
+		fmt.Fprintf(w, "%v (%v)\n", v, len(g.files[v]))
+		for _, file := range g.files[v] {
+			fmt.Fprintln(w, "\t"+file)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package movinfo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// hdrArgs are ffprobe arguments to read side data of the first video frame,
+// where HDR10 static metadata is. Reading only one frame keeps it fast.
+var hdrArgs = []string{"-select_streams", "v:0", "-read_intervals", "%+#1", "-show_frames"}
+
+// parseHDR parses HDR10 static metadata from side data of frames that ffprobe shows.
+// It formats them as x265 does for --master-display, followed by MaxCLL and MaxFALL.
+// (ex. G(13250,34500)B(7500,3000)R(34000,16000)WP(15635,16450)L(10000000,50) MaxCLL=1000 MaxFALL=400)
+// It returns "none" when there isn't the metadata.
+func parseHDR(data string) (string, error) {
+	display := map[string]string{}
+	light := map[string]string{}
+	for _, sd := range strings.Split(data, "[SIDE_DATA]")[1:] {
+		end := strings.Index(sd, "[/SIDE_DATA]")
+		if end == -1 {
+			return "", fmt.Errorf("unterminated side data")
+		}
+		kv := map[string]string{}
+		for r := (lineReader{s: sd[:end]}); r.next(); {
+			l := r.line
+			k, v, ok := strings.Cut(l, "=")
+			if ok {
+				kv[k] = v
+			}
+		}
+		switch kv["side_data_type"] {
+		case "Mastering display metadata":
+			if len(display) == 0 {
+				display = kv
+			}
+		case "Content light level metadata":
+			if len(light) == 0 {
+				light = kv
+			}
+		}
+	}
+	parts := []string{}
+	if len(display) != 0 {
+		// x265 uses chromaticity in 0.00002 unit, and luminance in 0.0001 nits.
+		md := ""
+		for _, c := range []struct {
+			name string
+			key  string
+		}{
+			{"G", "green"},
+			{"B", "blue"},
+			{"R", "red"},
+			{"WP", "white_point"},
+		} {
+			x, err := scaleRational(display[c.key+"_x"], 50000)
+			if err != nil {
+				return "", err
+			}
+			y, err := scaleRational(display[c.key+"_y"], 50000)
+			if err != nil {
+				return "", err
+			}
+			md += fmt.Sprintf("%v(%v,%v)", c.name, x, y)
+		}
+		maxLum, err := scaleRational(display["max_luminance"], 10000)
+		if err != nil {
+			return "", err
+		}
+		minLum, err := scaleRational(display["min_luminance"], 10000)
+		if err != nil {
+			return "", err
+		}
+		md += fmt.Sprintf("L(%v,%v)", maxLum, minLum)
+		parts = append(parts, md)
+	}
+	if len(light) != 0 {
+		parts = append(parts, "MaxCLL="+light["max_content"], "MaxFALL="+light["max_average"])
+	}
+	if len(parts) == 0 {
+		return "none", nil
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// scaleRational converts a rational like 34000/50000 to an integer in 1/unit.
+func scaleRational(r string, unit int) (int, error) {
+	v, err := parseRate(r)
+	if err != nil {
+		return 0, fmt.Errorf("invalid HDR metadata: %v", r)
+	}
+	return int(math.Round(v * float64(unit))), nil
+}
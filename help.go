@@ -0,0 +1,113 @@
+package movinfo
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flagGroup is a category of flags in the usage.
+type flagGroup struct {
+	name  string
+	flags []string
+}
+
+// flagGroups are the categories of the usage in order. Flags that aren't in
+// any of them are printed in the last group, other.
+var flagGroups = []flagGroup{
+	{"timecode", []string{"start", "end", "duration", "human-duration", "exclusive-end", "layout", "seconds", "frames-from-midnight", "start-from", "timecode-stream", "timecode-source", "timecode-order", "reel", "timecodes", "video-timecode-only", "strict-timecode-length", "end-rate", "drop", "force-drop", "ref-rate", "rounding", "computed-frames", "count-frames", "detelecine-end"}},
+	{"edit", []string{"trim", "keyframe-before", "frame-at", "samples", "quarters", "chapters", "set-timecode", "set-timecode-out"}},
+	{"video", []string{"fps", "resolution", "class", "codec", "encoder", "brand", "fragmented", "creation", "timezone", "creation-layout", "cover", "stereo3d", "projection", "bitrate", "bitrate-every", "gop", "gop-structure", "scan-type", "content-hash", "framerate"}},
+	{"color", []string{"colorspace", "color-info", "pixfmt", "hdr"}},
+	{"audio", []string{"loudness", "duration-diff", "duration-threshold"}},
+	{"check", []string{"check-drop", "check-timecode-rate", "check-end", "check-aspect", "standard", "strict", "min-duration", "max-duration", "min-resolution", "max-resolution"}},
+	{"output", []string{"all", "explain", "compact", "color", "json", "o", "out", "order", "group-by", "sidecar", "force", "exec"}},
+	{"batch", []string{"from-file", "fail-fast", "continue", "since", "watch", "watch-interval", "sequence", "segments", "diff", "ignore", "dry-run"}},
+	{"ffprobe", []string{"ffprobe", "ffmpeg", "ffprobe-arg", "pre-exec", "raw", "retries", "timeout"}},
+}
+
+// otherGroup is the group of flags that aren't in flagGroups.
+const otherGroup = "other"
+
+// groupNames returns names of the groups in order.
+func groupNames() []string {
+	names := make([]string, 0, len(flagGroups))
+	for _, g := range flagGroups {
+		names = append(names, g.name)
+	}
+	return names
+}
+
+// helpFlag is the value of -help. It is a bool flag, so -help alone prints all the groups,
+// and -help=group prints the group.
+type helpFlag struct {
+	group *string
+}
+
+func (h helpFlag) String() string {
+	if h.group == nil {
+		return ""
+	}
+	return *h.group
+}
+
+func (h helpFlag) Set(s string) error {
+	*h.group = s
+	return nil
+}
+
+func (h helpFlag) IsBoolFlag() bool {
+	return true
+}
+
+// printUsage prints flags of fs by their groups, or only flags of the group.
+// Group true is of -help without a group, that is all the groups.
+func printUsage(w io.Writer, fs *flag.FlagSet, group string) error {
+	if group == "true" {
+		group = ""
+	}
+	grouped := map[string]bool{"help": true}
+	groups := append([]flagGroup{}, flagGroups...)
+	for _, g := range flagGroups {
+		for _, name := range g.flags {
+			grouped[name] = true
+		}
+	}
+	other := flagGroup{name: otherGroup}
+	fs.VisitAll(func(f *flag.Flag) {
+		if !grouped[f.Name] {
+			other.flags = append(other.flags, f.Name)
+		}
+	})
+	if len(other.flags) != 0 {
+		groups = append(groups, other)
+	}
+	found := false
+	for _, g := range groups {
+		if group != "" && g.name != group {
+			continue
+		}
+		found = true
+		if group == "" {
+			fmt.Fprintf(w, "%v:\n", g.name)
+		}
+		// a FlagSet of the group prints its flags as PrintDefaults does.
+		sub := flag.NewFlagSet(g.name, flag.ContinueOnError)
+		sub.SetOutput(w)
+		for _, name := range g.flags {
+			f := fs.Lookup(name)
+			if f == nil {
+				continue
+			}
+			sub.Var(f.Value, f.Name, f.Usage)
+			// the value could be already set by the args.
+			sub.Lookup(f.Name).DefValue = f.DefValue
+		}
+		sub.PrintDefaults()
+	}
+	if !found {
+		return fmt.Errorf("unknown flag group: %v (%v)", group, strings.Join(groupNames(), ", "))
+	}
+	return nil
+}
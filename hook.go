@@ -0,0 +1,61 @@
+package movinfo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// runHook runs the command with the result of a mov as a json object in stdin,
+// and returns the json object the command prints to stdout.
+// The command could add, change or remove fields of the result.
+func runHook(command string, m map[string]any) (map[string]any, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty -exec command")
+	}
+	in, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin = bytes.NewReader(in)
+	c.Stderr = os.Stderr
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("-exec %v: %w", command, err)
+	}
+	hooked := map[string]any{}
+	if err := json.Unmarshal(out, &hooked); err != nil {
+		return nil, fmt.Errorf("-exec %v: invalid json output: %w", command, err)
+	}
+	return hooked, nil
+}
+
+// hookedFields returns fields of m, following the order of flds.
+// Fields that the hook added are following them in name order.
+func hookedFields(m map[string]any, flds []field) []field {
+	hooked := []field{}
+	known := map[string]bool{"file": true}
+	for _, f := range flds {
+		known[f.name] = true
+		if v, ok := m[f.name]; ok {
+			hooked = append(hooked, field{f.name, fmt.Sprint(v)})
+		}
+	}
+	extra := []string{}
+	for k := range m {
+		if !known[k] {
+			extra = append(extra, k)
+		}
+	}
+	sort.Strings(extra)
+	for _, k := range extra {
+		hooked = append(hooked, field{k, fmt.Sprint(m[k])})
+	}
+	return hooked
+}
@@ -0,0 +1,77 @@
+package movinfo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// seqNumber matches the frame number in printf-style pattern of an image sequence. (ex. %04d)
+var seqNumber = regexp.MustCompile(`%0?(\d*)d`)
+
+// isImageSequence reports whether the file is a pattern of an image sequence,
+// like plate.%04d.exr, rather than a mov.
+func isImageSequence(file string) bool {
+	return seqNumber.MatchString(filepath.Base(file))
+}
+
+// sequenceStart returns the first frame number of the image sequence on the disk.
+// ffprobe only looks for the first frame from 0 to 4 by itself, while plates often start from 1001.
+func sequenceStart(pattern string) (int, error) {
+	dir, name := filepath.Split(pattern)
+	loc := seqNumber.FindStringSubmatchIndex(name)
+	digits := `\d+`
+	if w := name[loc[2]:loc[3]]; w != "" {
+		// %04d still allows more digits than 4 for frame 10000.
+		digits = `\d{` + w + `,}`
+	}
+	re, err := regexp.Compile("^" + regexp.QuoteMeta(name[:loc[0]]) + "(" + digits + ")" + regexp.QuoteMeta(name[loc[1]:]) + "$")
+	if err != nil {
+		return 0, err
+	}
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	start := -1
+	for _, e := range entries {
+		m := re.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			continue
+		}
+		if start == -1 || n < start {
+			start = n
+		}
+	}
+	if start == -1 {
+		return 0, fmt.Errorf("no files of the image sequence: %v", pattern)
+	}
+	return start, nil
+}
+
+// sequenceArgs returns ffprobe arguments to read the image sequence as a clip in the framerate.
+func sequenceArgs(pattern, framerate string) ([]string, error) {
+	if framerate == "" {
+		return nil, fmt.Errorf("%w: %v", ErrMissingFramerate, pattern)
+	}
+	start, err := sequenceStart(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"-f", "image2", "-framerate", framerate, "-start_number", strconv.Itoa(start)}, nil
+}
+
+// isImage2 reports whether ffprobe read the input as an image sequence, from its overview.
+func isImage2(overview string) bool {
+	return strings.Contains(overview, "Input #0, image2, from ")
+}
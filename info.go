@@ -0,0 +1,179 @@
+package movinfo
+
+import "context"
+
+// Info is all the information movinfo could get from a mov.
+// Fields that aren't available for the mov are empty.
+type Info struct {
+	File       string `json:"file"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	Duration   string `json:"duration"`
+	FPS        string `json:"fps"`
+	Resolution string `json:"resolution"`
+	Class      string `json:"class"`
+	Codec      string `json:"codec"`
+	Colorspace string `json:"colorspace"`
+	PixFmt     string `json:"pixfmt"`
+	Encoder    string `json:"encoder"`
+	Brand      string `json:"brand"`
+	Cover      string `json:"cover"`
+	// Reel is the reel (tape) name of the mov, or none.
+	Reel string `json:"reel"`
+	// Creation is creation_time of the mov, or none.
+	Creation string `json:"creation"`
+	HDR      string `json:"hdr"`
+	// FramesDiff is nb_frames minus frames computed from duration and rate,
+	// when they don't match. It is 0 for most movs.
+	FramesDiff int `json:"frames_diff"`
+	// Warnings are problems of the mov that movinfo could work around.
+	Warnings []string `json:"warnings"`
+	// Streams are all the streams of the mov in the order of index,
+	// including audio, subtitle and data streams.
+	Streams []StreamInfo `json:"streams"`
+}
+
+// ProbeAll probes the file with ffprobe in PATH, and returns all the information of it.
+// Unlike the command, it doesn't fail for the information that isn't available.
+// It only fails when ffprobe fails, or the output isn't of a video.
+func ProbeAll(file string) (*Info, error) {
+	return probeAll(file, config{})
+}
+
+func probeAll(file string, cfg config) (*Info, error) {
+	ctx, cancel := cfg.probeContext()
+	defer cancel()
+	return probeAllWith(ctx, file, cfg, cfg.opener(ctx))
+}
+
+// probeAllWith probes the file opened with open for all the information.
+func probeAllWith(ctx context.Context, file string, cfg config, open Opener) (*Info, error) {
+	path, cleanup, err := openFile(file, open)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	cmd := cfg.ffprobeCmd()
+	args := allArgs(cfg)
+	if isImageSequence(path) {
+		seqArgs, err := sequenceArgs(path, cfg.framerate)
+		if err != nil {
+			return nil, err
+		}
+		args = append(seqArgs, args...)
+	}
+	out, err := probeRetry(ctx, cfg.retries, cmd, path, args...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.raw {
+		dumpRaw(out)
+	}
+	info, err := parseAll(out, cfg)
+	if err != nil {
+		return nil, err
+	}
+	info.File = file
+	if out, err := probe(ctx, cmd, path, hdrArgs...); err == nil {
+		info.HDR, _ = parseHDR(out)
+	}
+	return info, nil
+}
+
+// allArgs returns ffprobe arguments for all the streams information.
+func allArgs(cfg config) []string {
+	args := []string{}
+	if cfg.countFrames {
+		args = append(args, "-count_frames")
+	}
+	return append(args, "-show_streams")
+}
+
+// infoFields set the fields of Info in a config.
+var infoFields = []func(c *config){
+	func(c *config) { c.start = true },
+	func(c *config) { c.end = true },
+	func(c *config) { c.duration = true },
+	func(c *config) { c.fps = true },
+	func(c *config) { c.resolution = true },
+	func(c *config) { c.class = true },
+	func(c *config) { c.codec = true },
+	func(c *config) { c.colorspace = true },
+	func(c *config) { c.pixfmt = true },
+	func(c *config) { c.encoder = true },
+	func(c *config) { c.brand = true },
+	func(c *config) { c.cover = true },
+	func(c *config) { c.reel = true },
+	func(c *config) { c.creation = true },
+}
+
+// parseAll parses ffprobe output for all the fields.
+// It only fails when the output doesn't have a video stream.
+func parseAll(data string, cfg config) (*Info, error) {
+	all := cfg
+	for _, set := range infoFields {
+		set(&all)
+	}
+	// most movs have all the fields, so they are parsed at once.
+	res, err := parse(data, all)
+	if err != nil {
+		if _, err := parse(data, cfg); err != nil {
+			return nil, err
+		}
+		// a field is missing and fails the others, so they are parsed
+		// at once again without the fields that fail on their own.
+		all = cfg
+		for _, set := range infoFields {
+			c := cfg
+			set(&c)
+			if _, err := parse(data, c); err == nil {
+				set(&all)
+			}
+		}
+		if res, err = parse(data, all); err != nil {
+			return nil, err
+		}
+	}
+	info := &Info{
+		Start:      res.start,
+		End:        res.end,
+		Duration:   res.duration,
+		FPS:        res.fps,
+		Resolution: res.resolution,
+		Class:      res.class,
+		Codec:      res.codec,
+		Colorspace: res.colorspace,
+		PixFmt:     res.pixfmt,
+		Encoder:    res.encoder,
+		Brand:      res.brand,
+		Cover:      res.cover,
+		Reel:       res.reel,
+		Creation:   res.creation,
+		FramesDiff: res.framesDiff,
+		Warnings:   res.warnings,
+		Streams:    parseStreamInfos(data),
+	}
+	return info, nil
+}
+
+// result converts the Info to result, for printing it as the others.
+func (i *Info) result() result {
+	return result{
+		start:      i.Start,
+		end:        i.End,
+		duration:   i.Duration,
+		fps:        i.FPS,
+		resolution: i.Resolution,
+		class:      i.Class,
+		codec:      i.Codec,
+		colorspace: i.Colorspace,
+		pixfmt:     i.PixFmt,
+		encoder:    i.Encoder,
+		brand:      i.Brand,
+		cover:      i.Cover,
+		reel:       i.Reel,
+		creation:   i.Creation,
+		hdr:        i.HDR,
+		warnings:   i.Warnings,
+	}
+}
@@ -0,0 +1,85 @@
+package movinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyframeArgs are ffprobe arguments to show time and flags of every video packet,
+// one packet per line. (ex. 0.041708,K__) Packets are read without decoding them.
+var keyframeArgs = []string{"-select_streams", "v:0", "-show_entries", "packet=pts_time,flags", "-of", "csv=p=0"}
+
+// keyframe is the target of -keyframe-before, and the start of the mov to count it from.
+type keyframe struct {
+	start *Timecode
+	// rate is the real frame rate of the timecode.
+	rate float64
+	// target is seconds of the target from the time zero of the mov.
+	target float64
+}
+
+// probeKeyframeBefore runs ffprobe for keyframes of the video, and returns timecode
+// of the last keyframe at or before the target.
+func probeKeyframeBefore(ctx context.Context, cmd []string, file string, cfg config, k keyframe) (string, error) {
+	var times []float64
+	err := probeStream(ctx, cmd, file, keyframeArgs, func(r io.Reader) error {
+		var err error
+		times, err = readKeyframes(r)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	n, err := lastKeyframe(times, k.target, k.rate)
+	if err != nil {
+		return "", err
+	}
+	tc := *k.start
+	tc.Add(n)
+	return cfg.formatTimecode(&tc), nil
+}
+
+// readKeyframes reads packets of keyframeArgs format, and returns times of
+// the keyframes in order. Packets are in decoding order, so they are sorted.
+func readKeyframes(r io.Reader) ([]float64, error) {
+	times := []float64{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		t, flags, ok := strings.Cut(strings.TrimSpace(sc.Text()), ",")
+		if !ok || t == "N/A" || !strings.HasPrefix(flags, "K") {
+			continue
+		}
+		pts, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid packet time: %v", t)
+		}
+		times = append(times, pts)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(times) == 0 {
+		return nil, fmt.Errorf("no keyframes")
+	}
+	sort.Float64s(times)
+	return times, nil
+}
+
+// lastKeyframe returns frames from the time zero to the last keyframe at or before
+// the target seconds, in the rate. Times within half a frame are taken as the same frame.
+// It fails when the target is before the first keyframe.
+func lastKeyframe(times []float64, target, rate float64) (int, error) {
+	i := sort.Search(len(times), func(i int) bool {
+		return times[i] > target+0.5/rate
+	})
+	if i == 0 {
+		return 0, fmt.Errorf("%w: the first keyframe is at %v seconds", ErrNoKeyframe, formatSeconds(times[0]))
+	}
+	return int(math.Round(times[i-1] * rate)), nil
+}
@@ -0,0 +1,86 @@
+package movinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// limits are constraints of -min-duration, -max-duration, -min-resolution and
+// -max-resolution. A mov that doesn't meet them is an outlier.
+// Zero values are no constraint.
+type limits struct {
+	minDuration time.Duration
+	maxDuration time.Duration
+	// minClass and maxClass are labels of resolutionClasses. (ex. HD)
+	minClass string
+	maxClass string
+}
+
+// active reports whether any constraint is set.
+func (l limits) active() bool {
+	return l.duration() || l.resolution()
+}
+
+// duration reports whether a duration constraint is set.
+func (l limits) duration() bool {
+	return l.minDuration != 0 || l.maxDuration != 0
+}
+
+// resolution reports whether a resolution constraint is set.
+func (l limits) resolution() bool {
+	return l.minClass != "" || l.maxClass != ""
+}
+
+// check returns the constraints the mov of seconds long and in the resolution class
+// doesn't meet, like "duration 4.254s < 10s". It is empty when the mov isn't an outlier.
+// seconds and class are only used when there is a constraint for them.
+func (l limits) check(seconds float64, class string) []string {
+	problems := []string{}
+	d := time.Duration(seconds * float64(time.Second))
+	if l.minDuration != 0 && d < l.minDuration {
+		problems = append(problems, fmt.Sprintf("duration %vs < %v", formatSeconds(seconds), l.minDuration))
+	}
+	if l.maxDuration != 0 && d > l.maxDuration {
+		problems = append(problems, fmt.Sprintf("duration %vs > %v", formatSeconds(seconds), l.maxDuration))
+	}
+	// resolutionClasses are from the biggest, so a smaller rank is a bigger class.
+	if l.minClass != "" && classRank(class) > classRank(l.minClass) {
+		problems = append(problems, fmt.Sprintf("resolution %v < %v", class, l.minClass))
+	}
+	if l.maxClass != "" && classRank(class) < classRank(l.maxClass) {
+		problems = append(problems, fmt.Sprintf("resolution %v > %v", class, l.maxClass))
+	}
+	return problems
+}
+
+// classRank returns index of the class in resolutionClasses, or -1 when it isn't one.
+func classRank(label string) int {
+	for i, c := range resolutionClasses {
+		if c.label == label {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseLimitClass parses resolution of -min-resolution and -max-resolution,
+// that is a resolution class or width*height, into the class of it.
+// (ex. HD, 1920*1080, 1920x1080)
+func parseLimitClass(s string) (string, error) {
+	for _, c := range resolutionClasses {
+		if strings.EqualFold(c.label, s) {
+			return c.label, nil
+		}
+	}
+	width, height, ok := strings.Cut(strings.ReplaceAll(s, "x", "*"), "*")
+	if ok {
+		w, werr := strconv.Atoi(width)
+		h, herr := strconv.Atoi(height)
+		if werr == nil && herr == nil && w > 0 && h > 0 {
+			return classifyResolution(w, h), nil
+		}
+	}
+	return "", fmt.Errorf("invalid resolution: %v", s)
+}
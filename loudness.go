@@ -0,0 +1,101 @@
+package movinfo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// loudnessTimeout bounds -loudness when -timeout isn't given, as it decodes all the audio.
+const loudnessTimeout = 10 * time.Minute
+
+// loudnessUnavailable is the value of -loudness when it cannot be measured.
+const loudnessUnavailable = "unavailable"
+
+// loudnessArgs returns ffmpeg arguments that measure loudness of the first audio stream
+// of the file with ebur128 filter. The summary is printed to stderr.
+func loudnessArgs(file string) []string {
+	return []string{"-nostdin", "-hide_banner", "-nostats", "-i", file, "-map", "0:a:0", "-af", "ebur128=peak=true", "-f", "null", "-"}
+}
+
+// probeLoudness runs ffmpeg for integrated loudness and true peak of the file.
+// It returns loudnessUnavailable with the reason, when the file doesn't have audio
+// or ffmpeg fails.
+func probeLoudness(ctx context.Context, ffmpeg, file string) (string, string) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, loudnessTimeout)
+		defer cancel()
+	}
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	c := exec.CommandContext(ctx, ffmpeg, loudnessArgs(file)...)
+	c.Env = append(os.Environ(), "LC_ALL=C")
+	b, err := c.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return loudnessUnavailable, fmt.Sprintf("couldn't measure loudness: %v", ctx.Err())
+		}
+		if len(b) == 0 {
+			// ffmpeg couldn't run.
+			return loudnessUnavailable, fmt.Sprintf("couldn't measure loudness: %v", err)
+		}
+		return loudnessUnavailable, fmt.Sprintf("couldn't measure loudness: %v", lastLine(string(b)))
+	}
+	l, err := parseLoudness(string(b))
+	if err != nil {
+		return loudnessUnavailable, fmt.Sprintf("couldn't measure loudness: %v", err)
+	}
+	return l, ""
+}
+
+// parseLoudness parses the summary of ebur128 filter, and returns integrated loudness
+// and true peak. (ex. -23.0 LUFS, true peak -1.2 dBTP)
+func parseLoudness(out string) (string, error) {
+	idx := strings.LastIndex(out, "Summary:")
+	if idx == -1 {
+		return "", fmt.Errorf("no ebur128 summary")
+	}
+	section := ""
+	integrated := ""
+	peak := ""
+	for r := (lineReader{s: out[idx:]}); r.next(); {
+		l := strings.TrimSpace(r.line)
+		if strings.HasSuffix(l, ":") {
+			section = l
+			continue
+		}
+		k, v, ok := strings.Cut(l, ":")
+		if !ok {
+			continue
+		}
+		f := strings.Fields(v)
+		if len(f) == 0 {
+			continue
+		}
+		switch {
+		case section == "Integrated loudness:" && k == "I":
+			integrated = f[0]
+		case section == "True peak:" && k == "Peak":
+			peak = f[0]
+		}
+	}
+	if integrated == "" || peak == "" {
+		return "", fmt.Errorf("incomplete ebur128 summary")
+	}
+	if integrated == "-70.0" || integrated == "-inf" {
+		// ebur128 reports the absolute gate for silence.
+		return "", fmt.Errorf("audio is silent")
+	}
+	return fmt.Sprintf("%v LUFS, true peak %v dBTP", integrated, peak), nil
+}
+
+// lastLine returns the last non-empty line of s, which is usually the error of ffmpeg.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
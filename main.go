@@ -1,185 +1,1185 @@
-package main
+// Package movinfo gets timecode and other information of movs with ffprobe.
+// ProbeAll is for programs, and Main is the movinfo command.
+package movinfo
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
-// Timecode is timecode system that supports 24 and 30 base fps.
-// See introduction of drop frame timecode system at http://andrewduncan.net/timecodes/
-type Timecode struct {
-	// base is base frame rate for timecode
-	// ex) base frame rate of 29.976 fps is 30.
-	base  int
-	drop  bool
-	frame int
-}
-
-// NewTimecode creates new Timecode.
-func NewTimecode(code string, base int, drop bool) (*Timecode, error) {
-	if base != 24 && base != 30 {
-		return nil, fmt.Errorf("unknown base for timecode: %v:", base)
-	}
-	if base == 24 && drop {
-		// 23.98, 23.978 isn't a drop timecode system.
-		drop = false
-	}
-	if len(code) != 11 {
-		return nil, fmt.Errorf("invalid timecode: %v", code)
-	}
-	codes := [4]int{}
-	for i := 0; i < len(code); i += 3 {
-		n, err := strconv.Atoi(code[i : i+2])
-		if err != nil {
-			return nil, fmt.Errorf("invalid timecode: %v", code)
-		}
-		codes[i/3] = n
-	}
-	h := codes[0]
-	m := codes[1]
-	s := codes[2]
-	f := codes[3]
-	frame := 3600*h*base + 60*m*base + s*base + f
-	if drop {
-		// assume it is base 30
-		totalMinutes := 60*h + m
-		frame -= 2 * (totalMinutes - totalMinutes/10)
-	}
-	t := &Timecode{
-		base:  base,
-		drop:  drop,
-		frame: frame,
-	}
-	return t, nil
-}
-
-// Add adds frames to the Timecode.
-func (t *Timecode) Add(n int) {
-	t.frame += n
-}
-
-// String represents the Timecode as string.
-func (t *Timecode) String() string {
-	base := t.base
-	frame := t.frame
-	if t.drop {
-		// assume it is base 30
-		D := frame / 17982  // number of "full" 10 minutes chunks in drop frame system
-		M := frame % 17982  // remainder frames
-		d := (M - 2) / 1798 // number of 1 minute chunks those drop frames; M-2 because the first chunk will not drop frames
-		frame += 18*D + 2*d // 10 minutes chunks drop 18 frames; 1 minute chunks drop 2 frames
-	}
-	h := frame / base / 60 / 60 % 24
-	m := frame / base / 60 % 60
-	s := frame / base % 60
-	f := frame % base
-	codes := [4]int{h, m, s, f}
-	timecode := ""
-	for i, c := range codes {
-		if i == 1 || i == 2 {
-			timecode += ":"
+type config struct {
+	// startFrom replaces start timecode of the mov,
+	// or offsets it when it starts with plus sign. (ex. +00:00:10:00)
+	startFrom string
+	// startFromFlag is the flag of startFrom in errors. It is -start-from when empty.
+	startFromFlag string
+	// ffprobe is path of ffprobe binary. ffprobe in PATH is used when it is empty.
+	ffprobe string
+	// ffprobeArgs are extra arguments for ffprobe.
+	ffprobeArgs []string
+	// preExec is the command that prepares a mov for ffprobe, and prints the path to probe.
+	preExec string
+	// raw prints ffprobe output used for parsing.
+	raw bool
+	// retries is how many times to retry ffprobe when it fails.
+	retries int
+	// timeout is the deadline for probing a mov, including the retries.
+	timeout time.Duration
+	// strictTimecodeLength fails on timecode tags that aren't exactly HH:MM:SS:FF,
+	// instead of trimming and padding them.
+	strictTimecodeLength bool
+	// videoTimecodeOnly doesn't look for timecode in other streams,
+	// when the video stream doesn't have it.
+	videoTimecodeOnly bool
+	// strict rejects movs in unknown frame rate, even when the requested fields don't need it.
+	strict bool
+	// countFrames makes ffprobe count frames by decoding the mov, and use it instead of nb_frames.
+	// It is slow, but works for movs that don't have nb_frames.
+	countFrames bool
+	// shiftStart shifts start and end by start_time of the video, for movs whose
+	// timecode tag is for time zero rather than the first frame.
+	shiftStart bool
+	// layout is the layout for start and end. See Timecode.Format.
+	layout string
+	// seconds makes start and end in seconds from 00:00:00:00 instead of timecode.
+	seconds bool
+	// midnightFrames makes start and end number of frames from 00:00:00:00 instead of timecode.
+	midnightFrames bool
+	// exclusiveEnd makes end the frame after the last frame, so that
+	// Diff(start, end) == duration. Otherwise Diff(start, end)+1 == duration.
+	exclusiveEnd bool
+	// computedFrames uses round(duration * rate) for number of frames,
+	// when nb_frames doesn't match it. Some transcoders pad nb_frames.
+	computedFrames bool
+	// rounding is how fractional frames are rounded when converting seconds,
+	// or frames of another rate, to frames. See roundFrames.
+	rounding string
+	// forceDrop forces drop frame timecode for base 24, which is non-standard.
+	forceDrop bool
+	start     bool
+	end       bool
+	duration  bool
+	// humanDuration gets duration in real time like 1m23.4s.
+	humanDuration bool
+	fps           bool
+	resolution    bool
+	class         bool
+	// contentHash is number of video packets to hash for a fingerprint of the content.
+	contentHash int
+	// quarters gets timecodes of the first, 25%, 50%, 75% and the last frame of the mov.
+	quarters bool
+	// samples is number of sample points to get from the mov.
+	samples    int
+	codec      bool
+	colorspace bool
+	// colorInfo gets color primaries, transfer and matrix of the video, reading numeric codes.
+	colorInfo bool
+	// pixfmt gets chroma subsampling, bit depth, range and byte order of the pixel format.
+	pixfmt  bool
+	encoder bool
+	cover   bool
+	// reel gets the reel (tape) name of the mov, for conform.
+	reel bool
+	// brand gets major and compatible brands of the ftyp atom from the format metadata.
+	brand bool
+	// stereo3D gets stereoscopic 3D layout of the video from its side data.
+	stereo3D bool
+	// projection gets projection of spherical video from its side data.
+	projection bool
+	// creation gets creation_time of the mov in timezone, formatted with creationLayout.
+	creation       bool
+	timezone       string
+	creationLayout string
+	// fragmented probes the boxes of the mov again for movie fragments.
+	fragmented bool
+	// hdr probes the first frame again for HDR10 metadata.
+	hdr bool
+	// bitrate reads all the video packets again for peak and average bitrate.
+	bitrate bool
+	// loudness measures loudness of the audio with ffmpeg.
+	loudness bool
+	// ffmpeg is the ffmpeg binary for -loudness.
+	ffmpeg string
+	// gop reads picture type of all the video frames, and counts them by type.
+	gop bool
+	// gopStructure reads key flag and picture type of all the video frames,
+	// for open or closed GOP and scene cuts.
+	gopStructure bool
+	// scanType reads interlace flags of the first frames, for telling telecine from interlace.
+	scanType bool
+	// bitrateEvery makes bitrate only read a second in every bitrateEvery seconds.
+	bitrateEvery int
+	// checkDrop checks the timecode of the mov follows the drop frame rules of its rate.
+	checkDrop bool
+	// checkTimecodeRate checks the tmcd track counts frames in the rate of the video.
+	checkTimecodeRate bool
+	// checkEnd checks the end is start + duration - 1, as a cross-check of the metadata.
+	checkEnd bool
+	// endRate is the rate that counts start and end when the tmcd track has a different one.
+	endRate string
+	// checkAspect checks the mov of an anamorphic size doesn't have square pixels.
+	checkAspect bool
+	// explain adds where each value came from to the results. (ex. 102 (nb_frames))
+	explain bool
+	// trim is in and out timecode separated by comma, to make an ffmpeg command
+	// that extracts the frames from the mov. (ex. 01:00:10:00,01:00:19:23)
+	trim string
+	// keyframeBefore is a target timecode, to get the last keyframe at or before it.
+	keyframeBefore string
+	// frameAt is a time from the start of the mov, to get the frame at it. (ex. 00:01:30.5)
+	frameAt string
+	// chapters gets chapters of the mov with their start and end timecode.
+	chapters bool
+	// timecodeStream gets index of the stream the timecode came from.
+	timecodeStream bool
+	// timecodeSource gets the source of timecodeOrder the timecode came from.
+	timecodeSource bool
+	// timecodeOrder is comma separated sources of the start timecode, in order of preference.
+	// See defaultTimecodeOrder.
+	timecodeOrder string
+	// durationDiff gets the biggest difference between durations of the streams.
+	durationDiff bool
+	// durationThreshold is the difference that durationDiff takes as a mismatch.
+	durationThreshold time.Duration
+	// dropMode forces drop frame or non-drop timecode for 29.97 and 59.94, and 30 and 60,
+	// when their metadata is ambiguous. It is dropAuto by default.
+	dropMode string
+	// framerate is the frame rate of image sequences, which don't have one. (ex. 24000/1001)
+	framerate string
+	// timecodes gets timecodes of all the streams that have one.
+	timecodes bool
+	// limits only print movs that don't meet them, for finding outliers in a library.
+	limits limits
+	// refRate is the frame rate of the deliverable, that timecodes are counted in
+	// instead of the rate of the mov. (ex. 25 for a 23.976 mov in a PAL timeline)
+	refRate string
+	// detelecineEnd gets end of the mov both as it is, and in 23.976 after pulldown removal
+	// when it is telecined.
+	detelecineEnd bool
+	// standard checks resolution, scan and rate of the video conform to the broadcast standard.
+	standard string
+}
+
+// formatTimecode formats tc in the layout of the config, if it has one.
+func (c config) formatTimecode(tc *Timecode) string {
+	if c.layout != "" {
+		return tc.Format(c.layout)
+	}
+	return tc.String()
+}
+
+// fieldFlag is a flag that requests a field, with whether it is set in a config.
+type fieldFlag struct {
+	name string
+	set  func(c config) bool
+}
+
+// fieldFlags are the flags that request fields, in the order of the usage.
+// At least one of them, or -all, is needed to probe a mov.
+var fieldFlags = []fieldFlag{
+	{"start", func(c config) bool { return c.start }},
+	{"end", func(c config) bool { return c.end }},
+	{"duration", func(c config) bool { return c.duration }},
+	{"human-duration", func(c config) bool { return c.humanDuration }},
+	{"duration-diff", func(c config) bool { return c.durationDiff }},
+	{"fps", func(c config) bool { return c.fps }},
+	{"resolution", func(c config) bool { return c.resolution }},
+	{"class", func(c config) bool { return c.class }},
+	{"codec", func(c config) bool { return c.codec }},
+	{"colorspace", func(c config) bool { return c.colorspace }},
+	{"color-info", func(c config) bool { return c.colorInfo }},
+	{"pixfmt", func(c config) bool { return c.pixfmt }},
+	{"encoder", func(c config) bool { return c.encoder }},
+	{"brand", func(c config) bool { return c.brand }},
+	{"fragmented", func(c config) bool { return c.fragmented }},
+	{"creation", func(c config) bool { return c.creation }},
+	{"cover", func(c config) bool { return c.cover }},
+	{"stereo3d", func(c config) bool { return c.stereo3D }},
+	{"projection", func(c config) bool { return c.projection }},
+	{"hdr", func(c config) bool { return c.hdr }},
+	{"bitrate", func(c config) bool { return c.bitrate }},
+	{"gop", func(c config) bool { return c.gop }},
+	{"gop-structure", func(c config) bool { return c.gopStructure }},
+	{"content-hash", func(c config) bool { return c.contentHash > 0 }},
+	{"scan-type", func(c config) bool { return c.scanType }},
+	{"loudness", func(c config) bool { return c.loudness }},
+	{"timecode-stream", func(c config) bool { return c.timecodeStream }},
+	{"timecode-source", func(c config) bool { return c.timecodeSource }},
+	{"reel", func(c config) bool { return c.reel }},
+	{"timecodes", func(c config) bool { return c.timecodes }},
+	{"chapters", func(c config) bool { return c.chapters }},
+	{"trim", func(c config) bool { return c.trim != "" }},
+	{"keyframe-before", func(c config) bool { return c.keyframeBefore != "" }},
+	{"frame-at", func(c config) bool { return c.frameAt != "" }},
+	{"detelecine-end", func(c config) bool { return c.detelecineEnd }},
+	{"check-drop", func(c config) bool { return c.checkDrop }},
+	{"check-timecode-rate", func(c config) bool { return c.checkTimecodeRate }},
+	{"check-end", func(c config) bool { return c.checkEnd }},
+	{"standard", func(c config) bool { return c.standard != "" }},
+	{"check-aspect", func(c config) bool { return c.checkAspect }},
+	{"samples", func(c config) bool { return c.samples > 0 }},
+	{"quarters", func(c config) bool { return c.quarters }},
+	{"min-duration", func(c config) bool { return c.limits.minDuration != 0 }},
+	{"max-duration", func(c config) bool { return c.limits.maxDuration != 0 }},
+	{"min-resolution", func(c config) bool { return c.limits.minClass != "" }},
+	{"max-resolution", func(c config) bool { return c.limits.maxClass != "" }},
+}
+
+// fieldFlagNames returns the flags of fieldFlags and -all, for the error of no flag.
+func fieldFlagNames() string {
+	names := make([]string, 0, len(fieldFlags)+1)
+	for _, f := range fieldFlags {
+		names = append(names, "-"+f.name)
+	}
+	return strings.Join(append(names, "-all"), ", ")
+}
+
+// requested reports whether any field is requested.
+func (c config) requested() bool {
+	for _, f := range fieldFlags {
+		if f.set(c) {
+			return true
 		}
-		if i == 3 {
-			if t.drop {
-				timecode += ";"
-			} else {
-				timecode += ":"
-			}
+	}
+	return false
+}
+
+// computedStart reports whether the start is computed in the rate of the video,
+// rather than printed as the timecode tag is.
+func (c config) computedStart() bool {
+	return c.startFrom != "" || c.shiftStart || c.seconds || c.midnightFrames || c.layout != "" || (c.dropMode != dropAuto && c.dropMode != "")
+}
+
+// needsFrames reports whether a requested field needs the number of frames of the video.
+func (c config) needsFrames() bool {
+	return c.end || c.checkEnd || c.detelecineEnd || c.samples > 0 || c.quarters || c.duration || c.humanDuration || c.trim != "" || c.frameAt != "" || c.limits.duration()
+}
+
+// drop modes for config.dropMode.
+const (
+	// dropAuto uses drop frame for 29.97 and 59.94, and non-drop for the others,
+	// or what the tmcd track says.
+	dropAuto = "auto"
+	dropOn   = "drop"
+	dropOff  = "non-drop"
+)
+
+// rounding modes for config.rounding.
+const (
+	// roundHalfUp rounds to the nearest frame, and half a frame up. It is the default.
+	roundHalfUp = "round"
+	// roundFloor drops the fraction, so a frame is only counted when it is complete.
+	roundFloor = "floor"
+)
+
+// roundFrames rounds fractional frames x in the rounding mode of the config.
+// It is used for chapter times of -chapters and for converting video frames
+// to frames of the timecode track when their rates differ.
+func (c config) roundFrames(x float64) int {
+	switch c.rounding {
+	case roundFloor:
+		// tolerate float errors like 47.99999999 for 48 frames.
+		return int(math.Floor(x + 1e-6))
+	default:
+		return int(math.Floor(x + 0.5))
+	}
+}
+
+type result struct {
+	start         string
+	end           string
+	duration      string
+	humanDuration string
+	fps           string
+	resolution    string
+	class         string
+	samples       string
+	quarters      string
+	// quarterPoints are timecodes of quarters, for json.
+	quarterPoints  [5]string
+	codec          string
+	colorspace     string
+	colorInfo      string
+	pixfmt         string
+	encoder        string
+	brand          string
+	fragmented     string
+	creation       string
+	cover          string
+	stereo3D       string
+	projection     string
+	hdr            string
+	bitrate        string
+	gop            string
+	gopStructure   string
+	contentHash    string
+	scanType       string
+	loudness       string
+	timecodeStream string
+	timecodeSource string
+	reel           string
+	timecodes      string
+	durationDiff   string
+	// outlier is the constraints of -min-duration and the others the mov doesn't meet.
+	outlier           string
+	chapters          string
+	trim              string
+	dropCheck         string
+	timecodeRateCheck string
+	endCheck          string
+	standard          string
+	aspectCheck       string
+	// codecInfo is parts of codec, for json.
+	codecInfo codecInfo
+	// framesDiff is nb_frames minus frames computed from duration and rate,
+	// when they differ more than maxFramesDiff.
+	framesDiff int
+	// trimStart and trimLength are seconds from the start of the mov
+	// and length of the frames for -trim.
+	trimStart  float64
+	trimLength float64
+	// keyframe is the target of -keyframe-before, for probing keyframes later.
+	keyframe       keyframe
+	keyframeBefore string
+	// telecine is the ends of -detelecine-end, for formatting after probing the scan type.
+	telecine      telecine
+	detelecineEnd string
+	frameAt       string
+	// length is duration of the video stream in seconds for -bitrate, or 0 when it is unknown.
+	length float64
+	// base is the timecode base of start and end, when end is computed.
+	base int
+	// sources are where values of the fields came from, by field name, for -explain.
+	sources map[string]string
+	// warnings are problems of the mov that parse could work around.
+	warnings []string
+}
+
+// field is a named value of a result.
+type field struct {
+	name  string
+	value string
+}
+
+// detailFields returns non-empty parts of the fields that are combined for reading,
+// like profile of codec. They are only in json, where they are easier to use.
+func (r result) detailFields() []field {
+	all := []field{
+		{"codec_name", r.codecInfo.name},
+		{"codec_profile", r.codecInfo.profile},
+		{"codec_level", r.codecInfo.level},
+		{"codec_pix_fmt", r.codecInfo.pixFmt},
+	}
+	if r.quarters != "" {
+		for i, p := range quarterPercents {
+			all = append(all, field{fmt.Sprintf("quarter_%v", p), r.quarterPoints[i]})
 		}
-		tc := strconv.Itoa(c)
-		if len(tc) == 1 {
-			tc = "0" + tc
+	}
+	flds := make([]field, 0, len(all))
+	for _, f := range all {
+		if f.value != "" {
+			flds = append(flds, f)
 		}
-		timecode += tc
 	}
-	return timecode
+	return flds
 }
 
-type config struct {
-	start      bool
-	end        bool
-	duration   bool
-	fps        bool
-	resolution bool
-	codec      bool
-	colorspace bool
+// fields returns non-empty values of the result in the output order.
+func (r result) fields() []field {
+	all := r.allFields()
+	flds := make([]field, 0, len(all))
+	for _, f := range all {
+		if f.value != "" {
+			flds = append(flds, f)
+		}
+	}
+	return flds
 }
 
-type result struct {
-	start      string
-	end        string
-	duration   string
-	fps        string
-	resolution string
-	codec      string
-	colorspace string
+// allFields returns all the values of the result in the default output order.
+func (r result) allFields() []field {
+	return []field{
+		{"start", r.start},
+		{"end", r.end},
+		{"duration", r.duration},
+		{"human_duration", r.humanDuration},
+		{"duration_diff", r.durationDiff},
+		{"fps", r.fps},
+		{"resolution", r.resolution},
+		{"class", r.class},
+		{"codec", r.codec},
+		{"colorspace", r.colorspace},
+		{"color_info", r.colorInfo},
+		{"pixfmt", r.pixfmt},
+		{"encoder", r.encoder},
+		{"brand", r.brand},
+		{"fragmented", r.fragmented},
+		{"creation", r.creation},
+		{"cover", r.cover},
+		{"stereo3d", r.stereo3D},
+		{"projection", r.projection},
+		{"hdr", r.hdr},
+		{"bitrate", r.bitrate},
+		{"gop", r.gop},
+		{"gop_structure", r.gopStructure},
+		{"content_hash", r.contentHash},
+		{"scan_type", r.scanType},
+		{"loudness", r.loudness},
+		{"timecode_stream", r.timecodeStream},
+		{"timecode_source", r.timecodeSource},
+		{"reel", r.reel},
+		{"timecodes", r.timecodes},
+		{"chapters", r.chapters},
+		{"trim", r.trim},
+		{"keyframe_before", r.keyframeBefore},
+		{"frame_at", r.frameAt},
+		{"detelecine_end", r.detelecineEnd},
+		{"drop_check", r.dropCheck},
+		{"timecode_rate_check", r.timecodeRateCheck},
+		{"end_check", r.endCheck},
+		{"standard", r.standard},
+		{"aspect_check", r.aspectCheck},
+		{"samples", r.samples},
+		{"quarters", r.quarters},
+		{"outlier", r.outlier},
+	}
+}
+
+// parseFieldNames parses comma separated field names, like -order.
+func parseFieldNames(s string) ([]string, error) {
+	known := map[string]bool{}
+	for _, name := range fieldNames() {
+		known[name] = true
+	}
+	order := []string{}
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		listed, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field: %v (fields are %v)", name, strings.Join(fieldNames(), ", "))
+		}
+		if !listed {
+			return nil, fmt.Errorf("field listed twice: %v", name)
+		}
+		known[name] = false
+		order = append(order, name)
+	}
+	return order, nil
+}
+
+// fieldNames returns names of all the fields in the default order.
+// They are in snake_case unlike the flags. (ex. human_duration of -human-duration)
+func fieldNames() []string {
+	names := []string{}
+	for _, f := range (result{}).allFields() {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+// orderFields moves the fields in the order to the front, in that order.
+// The other fields follow them in their order.
+func orderFields(flds []field, order []string) []field {
+	if len(order) == 0 {
+		return flds
+	}
+	ordered := make([]field, 0, len(flds))
+	for _, name := range order {
+		for _, f := range flds {
+			if f.name == name {
+				ordered = append(ordered, f)
+			}
+		}
+	}
+	for _, f := range flds {
+		listed := false
+		for _, name := range order {
+			if f.name == name {
+				listed = true
+			}
+		}
+		if !listed {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered
 }
 
-func main() {
+// Main runs the movinfo command with the flags and files of os.Args.
+func Main() {
 	log.SetFlags(0)
 	cfg := config{}
+	jsonOut := false
+	sequence := false
+	segments := false
+	dry := false
+	hook := ""
+	compact := false
+	colorMode := colorAuto
+	all := false
+	since := ""
+	watch := false
+	failFast := false
+	keepGoing := false
+	outPath := ""
+	watchInterval := time.Duration(0)
+	minResolution := ""
+	maxResolution := ""
+	sidecarOut := false
+	force := false
+	setTC := ""
+	setTCOut := ""
+	groupBy := ""
+	fieldOrder := ""
+	diffMode := false
+	ignore := ""
+	fromFile := ""
+	help := ""
 	flag.BoolVar(&cfg.start, "start", false, "get start frame timecode from the mov.")
-	flag.BoolVar(&cfg.end, "end", false, "get end frame timecode from the mov.")
+	flag.BoolVar(&cfg.end, "end", false, "get end frame timecode from the mov. when the video starts later than zero (start_time), the end is shifted as much, as the timecode tag is for the time zero.")
 	flag.BoolVar(&cfg.duration, "duration", false, "get duration in frame from the mov.")
+	flag.BoolVar(&cfg.durationDiff, "duration-diff", false, "get the biggest difference between durations of audio and video streams in seconds, as ok or mismatch, the difference, and the longest and the shortest stream separated by tab. (ex. mismatch\t0.250\tstream 1 video 4.254\tstream 0 audio 4.004)")
+	flag.DurationVar(&cfg.durationThreshold, "duration-threshold", 100*time.Millisecond, "difference of -duration-diff that is a mismatch.")
+	flag.BoolVar(&cfg.checkDrop, "check-drop", false, "check the timecode of the mov uses drop frame notation only for 29.97 and 59.94 fps, and isn't a frame drop frame skips. it gets ok or the problem.")
+	flag.BoolVar(&cfg.checkEnd, "check-end", false, "check the end is start + duration - 1 frames, as a cross-check of the metadata. start and end are the ones -start and -end print. it fails when the tmcd track has a different rate from the video. it gets ok or the problem.")
+	flag.BoolVar(&cfg.checkTimecodeRate, "check-timecode-rate", false, "check the tmcd track counts frames in the rate of the video, as a 30 fps tmcd track of 23.976 video makes start and end ambiguous. timecode of half the rate, like 29.97 of 59.94 video, is fine. it gets ok or the problem.")
+	flag.StringVar(&cfg.endRate, "end-rate", endRateTimecode, "rate that counts start and end when the tmcd track has a different rate from the video. timecode counts the frames of the video in the rate of the tmcd track, and video reads the timecode in the rate of the video. (timecode, video)")
+	flag.StringVar(&cfg.trim, "trim", "", "get an ffmpeg command that extracts frames from in to out timecode of the mov, both inclusive. (ex. 01:00:10:00,01:00:19:23) -ss is before -i for fast seeking, which is frame accurate only when re-encoding, not with -c copy.")
+	flag.StringVar(&cfg.frameAt, "frame-at", "", "get the frame nearest to the time from the start of the mov in its real frame rate, as frame index from 0 and timecode separated by tab. the time is seconds, or colon separated like a clock. (ex. -frame-at 00:01:30.5 gets 2712\t01:01:30;14 in 29.97 from 01:00:00;00)")
+	flag.StringVar(&setTC, "set-timecode", "", "write a copy of the mov with the timecode to -set-timecode-out with ffmpeg, without re-encoding. the timecode is checked against the rate of the mov, and could be relative to the current one as -start-from. (ex. 01:00:00:00, +00:00:10:00)")
+	flag.StringVar(&setTCOut, "set-timecode-out", "", "path of the mov -set-timecode writes. it is never the mov itself, and isn't overwritten without -force.")
+	flag.BoolVar(&cfg.detelecineEnd, "detelecine-end", false, "get end timecode as it is, and in 23.976 over the frames after 3:2 pulldown removal when -scan-type finds the 29.97 mov is telecined. (ex. literal 01:02:00;03, detelecined 01:01:59:23)")
+	flag.StringVar(&cfg.keyframeBefore, "keyframe-before", "", "get timecode of the last keyframe at or before the timecode, for cutting on a clean boundary. it reads all the video packets. (ex. 01:00:10:00)")
+	flag.BoolVar(&cfg.humanDuration, "human-duration", false, "get duration in real time rounded to 0.1 second, for reports. (ex. 1m23.4s, 1h2m0.5s)")
+	flag.BoolVar(&cfg.chapters, "chapters", false, "get chapters of the mov, as title, start and end timecode separated by tab, or none.")
+	flag.BoolVar(&cfg.timecodeStream, "timecode-stream", false, "get index of the stream that the timecode came from, or format. the stream is chosen by -timecode-order. it warns when other sources have a different one.")
+	flag.BoolVar(&cfg.timecodeSource, "timecode-source", false, "get the source of -timecode-order that the timecode came from, or -start-from. (video, streams, format, tmcd-frame)")
+	flag.StringVar(&cfg.timecodeOrder, "timecode-order", defaultTimecodeOrder, "comma separated sources of the start timecode in order of preference. video, streams and format are timecode tags of the video stream, the first other stream that has one and the format. tmcd-frame is the frame number in the tmcd track, that could only be the last. (ex. format,video)")
+	flag.BoolVar(&cfg.reel, "reel", false, "get the reel (tape) name of the mov from reel_name of the tmcd or video stream, or com.apple.quicktime.reel of the format, for matching it to the camera original in an EDL. none when the mov doesn't have it.")
+	flag.BoolVar(&cfg.timecodes, "timecodes", false, "get timecodes of all the streams, as stream index, timecode and rate separated by tab, one stream per line. (ex. source and record timecode tracks)")
+	flag.BoolVar(&cfg.videoTimecodeOnly, "video-timecode-only", false, "only use timecode of the video stream. by default other streams are searched when the video stream doesn't have it.")
+	flag.BoolVar(&cfg.strictTimecodeLength, "strict-timecode-length", false, "fail on timecode tags that aren't exactly HH:MM:SS:FF. by default whitespace around them is trimmed and fields of a single digit are padded. (ex. '1:00:00;00 ')")
+	flag.BoolVar(&cfg.explain, "explain", false, "add where each value came from after it, for auditing. (ex. 102 (nb_frames)) with -json, they are in \"sources\" by field name. -compact doesn't have them.")
+	flag.BoolVar(&cfg.strict, "strict", false, "fail when the fps of the mov isn't a known one, whatever flags are set.")
+	flag.BoolVar(&cfg.countFrames, "count-frames", false, "count frames by decoding the mov, for the movs without nb_frames. it is slow.")
+	flag.StringVar(&cfg.layout, "layout", "", "layout of start and end timecode. HH, MM, SS, FF are replaced with hours, minutes, seconds, frames and hh with 12-hour clock hours. (ex. HH:MM:SS.FF)")
+	flag.BoolVar(&cfg.seconds, "seconds", false, "get start and end in seconds from 00:00:00:00 in the real frame rate, instead of timecode. (ex. 4.213 for 00:00:04:05 in 23.976)")
+	flag.BoolVar(&cfg.midnightFrames, "frames-from-midnight", false, "get start and end as number of frames from 00:00:00:00, instead of timecode. drop frame timecode doesn't count the frames it skips. (ex. 107892 for 01:00:00;00 in 29.97)")
+	flag.BoolVar(&cfg.shiftStart, "shift-start", false, "shift start and end by start_time of the video stream, for movs whose timecode tag is for time zero instead of the first frame. a start_time of a couple of frames is usual for B-frames or an edit list, so it isn't shifted by default. end - start + 1 is still duration.")
+	flag.BoolVar(&cfg.exclusiveEnd, "exclusive-end", false, "get end as the frame after the last frame, so end - start == duration. by default end is the last frame, so end - start + 1 == duration.")
 	flag.BoolVar(&cfg.fps, "fps", false, "get fps from the mov.")
-	flag.BoolVar(&cfg.resolution, "resolution", false, "get resolution of the mov.")
+	flag.BoolVar(&cfg.resolution, "resolution", false, "get resolution of the mov. it gets both coded and display resolution when they differ for rotation or non-square pixels. (ex. coded 1440*1080, display 1920*1080)")
+	flag.BoolVar(&cfg.class, "class", false, "get resolution class of the mov. (SD, HD-720, HD, DCI-2K, UHD-4K, DCI-4K, UHD-8K)")
 	flag.BoolVar(&cfg.codec, "codec", false, "get codec of the mov.")
 	flag.BoolVar(&cfg.colorspace, "colorspace", false, "get colorspace of the mov.")
-	flag.Parse()
+	flag.BoolVar(&cfg.colorInfo, "color-info", false, "get color primaries, transfer and matrix of the mov. numeric codes of legacy QuickTime (NCLC) are read as names, and the overview fills unknown ones. (ex. primaries bt709, transfer bt709, matrix bt709)")
+	flag.BoolVar(&cfg.pixfmt, "pixfmt", false, "get chroma subsampling, bit depth, range and byte order of the pixel format. byte order is omitted for 8-bit. (ex. 4:2:2 10-bit limited little-endian)")
+	flag.BoolVar(&cfg.encoder, "encoder", false, "get the application or encoder that wrote the mov.")
+	flag.BoolVar(&cfg.brand, "brand", false, "get major brand and compatible brands of the mov, or none. QuickTime-only tools need qt. (ex. isom (compatible isom, iso2, avc1, mp41))")
+	flag.BoolVar(&cfg.fragmented, "fragmented", false, "get whether the mp4 is fragmented, with movie fragments (moof) for DASH and HLS packaging, or progressive with all the samples in moov. it probes the mov again with trace logs.")
+	flag.BoolVar(&cfg.creation, "creation", false, "get creation_time of the mov in -timezone. creation_time without a zone is taken as UTC. none when the mov doesn't have it.")
+	flag.StringVar(&cfg.timezone, "timezone", "UTC", "timezone of -creation. UTC, Local, a name of the tz database or an offset from UTC. (ex. Asia/Tokyo, +09:00)")
+	flag.StringVar(&cfg.creationLayout, "creation-layout", time.RFC3339, "layout of -creation, as the reference time of Go's time package. (ex. \"2006-01-02 15:04 MST\")")
+	flag.BoolVar(&cfg.cover, "cover", false, "get codec and resolution of the cover image attached to the mov, or none. (ex. mjpeg 600*600)")
+	flag.BoolVar(&cfg.stereo3D, "stereo3d", false, "get stereoscopic 3D layout of the mov, or 2D. (ex. side by side, top and bottom (inverted))")
+	flag.BoolVar(&cfg.projection, "projection", false, "get projection of 360 video from its spherical metadata and the degrees it covers horizontally, or none. (ex. equirectangular (360), cubemap (360), tiled equirectangular (180))")
+	flag.BoolVar(&cfg.hdr, "hdr", false, "get HDR10 mastering display metadata, MaxCLL and MaxFALL of the mov, or none. it reads the first frame.")
+	flag.BoolVar(&cfg.bitrate, "bitrate", false, "get peak and average bitrate of the video in Mb/s. peak is of the busiest second. it reads all the packets, so it is slow for long movs.")
+	flag.BoolVar(&cfg.gop, "gop", false, "get counts of I, P and B frames of the video and the ratio of I frames. it decodes all the frames, so it is slow.")
+	flag.BoolVar(&cfg.gopStructure, "gop-structure", false, "get whether GOPs of the video are open or closed, their usual length and an estimate of scene cuts from extra I frames. it decodes all the frames, so it is slow. (ex. closed, GOP 24 frames, 3 scene cuts)")
+	flag.IntVar(&cfg.contentHash, "content-hash", 0, "get SHA-256 of the data of the first n video packets, to find movs of the same content. metadata and the container aren't hashed, so a mov re-wrapped without re-encoding has the same hash. it reads the packets without decoding them. (ex. -content-hash 100)")
+	flag.BoolVar(&cfg.scanType, "scan-type", false, fmt.Sprintf("get scan type of the video from interlace flags of its first %v frames. (progressive, interlaced (top field first), interlaced (bottom field first), telecine (3:2 pulldown), mixed)", scanFrames))
+	flag.BoolVar(&cfg.loudness, "loudness", false, "get integrated loudness and true peak of the first audio stream with ffmpeg's ebur128 filter, or unavailable. it decodes all the audio, so it is slow. it gives up after -timeout, or 10 minutes. (ex. -23.0 LUFS, true peak -1.2 dBTP)")
+	flag.IntVar(&cfg.bitrateEvery, "bitrate-every", 0, "only read a second in every n seconds for -bitrate, to make it faster for long movs.")
+	flag.IntVar(&cfg.samples, "samples", 0, "get n evenly spaced sample points of the mov, as timecode and seconds from the start for ffmpeg -ss. (ex. 00:00:10:00\t10.010)")
+	flag.BoolVar(&cfg.quarters, "quarters", false, "get timecodes of the first frame, the frames at 25%, 50% and 75%, and the last frame of the mov, for QC. a position between two frames is the earlier one. with -json, they are also quarter_0 to quarter_100. (ex. 50%\t01:00:10;12)")
+	flag.StringVar(&cfg.startFrom, "start-from", "", "replace start timecode of the mov with the timecode, or offset it when the timecode starts with +. -end is recomputed from it.")
+	flag.BoolVar(&cfg.computedFrames, "computed-frames", false, "use duration * rate for number of frames when nb_frames doesn't match it. by default it only warns.")
+	flag.StringVar(&cfg.rounding, "rounding", roundHalfUp, "rounding of fractional frames when converting seconds or frames of another rate to frames, for -chapters and for timecode tracks in different rate. (round, floor)")
+	flag.StringVar(&cfg.dropMode, "drop", dropAuto, "timecode system of the mov. auto is drop frame for 29.97 and 59.94 fps and non-drop for the others, or as the tmcd track says. drop and non-drop force it for the movs of 30 and 60 bases with ambiguous metadata, and start is also printed in it. (auto, drop, non-drop)")
+	flag.StringVar(&cfg.standard, "standard", "", fmt.Sprintf("check resolution class, scan and frame rate of the video conform to the broadcast standard. it gets ok or the problem. (%v)", strings.Join(standardNames(), ", ")))
+	flag.BoolVar(&cfg.checkAspect, "check-aspect", false, "check the mov of a coded size only used with non-square pixels, like 1440*1080 of HDV, doesn't have square or unknown sample aspect ratio that displays it squished. it gets ok or the problem.")
+	flag.BoolVar(&cfg.forceDrop, "force-drop", false, "(advanced) treat 23.976 timecode as drop frame. it is non-standard, use it only for files that need it.")
+	flag.StringVar(&cfg.refRate, "ref-rate", "", "count start, end and the others in timecode of the rate, instead of the rate of the mov. the mov's frames are converted in real time. (ex. -ref-rate 25 for a 23.976 mov in a 25 fps deliverable)")
+	flag.StringVar(&cfg.framerate, "framerate", "", "frame rate of image sequences, that are given as printf-style pattern. it is required for them. (ex. -framerate 24000/1001 plate.%04d.exr)")
+	flag.StringVar(&cfg.ffprobe, "ffprobe", "ffprobe", "path of ffprobe binary.")
+	flag.StringVar(&cfg.ffmpeg, "ffmpeg", "ffmpeg", "path of ffmpeg binary, for -loudness.")
+	flag.Var(argsFlag{&cfg.ffprobeArgs}, "ffprobe-arg", "pass an extra argument to ffprobe, before the arguments of movinfo. repeat it for more arguments. (ex. -ffprobe-arg=-probesize -ffprobe-arg=50M)")
+	flag.StringVar(&cfg.preExec, "pre-exec", "", "run the command with the mov as the last argument before probing it, and probe the path it prints instead, or the mov when it prints nothing. the command is split at spaces without a shell, so quotes are kept as they are. it is killed after -timeout as ffprobe. (ex. for a mov in a tar or staged from tape)")
+	flag.BoolVar(&cfg.raw, "raw", false, "print the ffprobe output used for parsing to stderr, for debugging.")
+	flag.IntVar(&cfg.retries, "retries", 0, "retry ffprobe n times with backoff when it fails to execute or to open the mov, only for the first probe of a mov. a corrupt mov isn't retried. (ex. for networked storage)")
+	flag.DurationVar(&cfg.timeout, "timeout", 0, "give up probing a mov after the duration, including retries. (ex. 30s)")
+	flag.StringVar(&hook, "exec", "", "pipe the result as a json object to the command, and print the json object it returns instead. the command can add its own fields.")
+	flag.BoolVar(&all, "all", false, "get all the information available from the mov. unavailable ones are skipped instead of failing.")
+	flag.BoolVar(&compact, "compact", false, "print results in a single line of key=value pairs. values with spaces are quoted.")
+	flag.StringVar(&colorMode, "color", colorAuto, "color the results, green for values and red for problems like a mismatch. auto colors only when printing to a terminal. json and -compact are never colored. (auto, always, never)")
+	flag.BoolVar(&jsonOut, "json", false, "print results as a json object. codec is also split into codec_name, codec_profile, codec_level and codec_pix_fmt. errors are also printed to stderr as json.")
+	flag.StringVar(&outPath, "o", "", "write results to the file instead of stdout, truncating it when the first result is written. results of all the movs go to the file. errors still go to stderr.")
+	flag.StringVar(&outPath, "out", "", "same as -o.")
+	flag.BoolVar(&sidecarOut, "sidecar", false, "write all the information of each mov to a json file next to it, named like a.mov"+sidecarExt+", and print its path. the mov is skipped when its sidecar is newer than it.")
+	flag.StringVar(&fieldOrder, "order", "", "comma separated field names to print first, in the order. the other fields follow them in the default order. field names are snake_case, unlike the flags. (ex. -order resolution,human_duration)")
+	flag.StringVar(&groupBy, "group-by", "", "print the movs grouped by the field, from the biggest group, instead of their results. the field should also be requested. with -json, it is an object of the movs by the values. (ex. -group-by resolution -resolution)")
+	flag.BoolVar(&force, "force", false, "write sidecars of -sidecar even when they are newer than the movs, and overwrite -set-timecode-out.")
+	flag.BoolVar(&dry, "dry-run", false, "print the ffprobe commands to run for the mov, without running them.")
+	flag.BoolVar(&segments, "segments", false, "check given movs, that are segments of a reel in the order, chain in timecode without a gap or overlap. it gets continuous with the whole range, or the first discontinuity. it needs two or more movs.")
+	flag.BoolVar(&diffMode, "diff", false, "compare the requested fields of two movs, or start, end, duration, fps, resolution, codec and colorspace. different fields are marked with - for the first mov and + for the second, and the exit code is 3. with -json, it is an object of the different fields.")
+	flag.StringVar(&ignore, "ignore", "", "comma separated field names that -diff doesn't compare. (ex. -ignore codec,colorspace)")
+	flag.StringVar(&since, "since", "", "skip the mov when it isn't modified since the time, that is a duration before now or a RFC 3339 timestamp. (ex. 24h, 2024-05-01T00:00:00+09:00)")
+	flag.BoolVar(&watch, "watch", false, "watch the directory for new movs, and print the result of each mov after it is completely written. the file name comes before the result, except in -json. it runs until interrupted.")
+	flag.DurationVar(&watchInterval, "watch-interval", 2*time.Second, "how often -watch looks into the directory. a new mov should keep the same size for two looks to be probed.")
+	flag.DurationVar(&cfg.limits.minDuration, "min-duration", 0, "only print movs shorter than the duration, with why they are outliers. the exit code is 3 when any mov is an outlier. (ex. 10s)")
+	flag.DurationVar(&cfg.limits.maxDuration, "max-duration", 0, "only print movs longer than the duration, like -min-duration. (ex. 2h)")
+	flag.StringVar(&minResolution, "min-resolution", "", "only print movs of a smaller resolution class than the resolution, like -min-duration. it is a class of -class or width*height. (ex. HD, 3840*2160)")
+	flag.StringVar(&maxResolution, "max-resolution", "", "only print movs of a bigger resolution class than the resolution, like -min-resolution.")
+	flag.StringVar(&fromFile, "from-file", "", "read paths of movs from the file, one path per line, or from stdin when it is -. they follow the movs of the arguments. it is for more movs than the command line could have. (ex. find /mnt -name '*.mov' | movinfo -start -from-file -)")
+	flag.BoolVar(&failFast, "fail-fast", false, "stop at the first mov that fails, when multiple movs are given.")
+	flag.BoolVar(&keepGoing, "continue", false, "keep going after a mov fails, when multiple movs are given. it is the default. either way the exit code is 1 when any mov failed.")
+	flag.BoolVar(&sequence, "sequence", false, "check given movs are contiguous in timecode, and report gaps or overlaps between them in frames. it needs two or more movs. with -json, a pair is an object of a line.")
+	flag.Var(helpFlag{&help}, "help", fmt.Sprintf("print the flags, or only the flags of the group. (%v) (ex. -help=timecode)", strings.Join(groupNames(), ", ")))
+	flag.Usage = func() {
+		printUsage(flag.CommandLine.Output(), flag.CommandLine, "")
+	}
+	if err := parseFlags(flag.CommandLine, os.Getenv(defaultsEnv), os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
+	if help != "" {
+		if err := printUsage(flag.CommandLine.Output(), flag.CommandLine, help); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	args := flag.Args()
-	if len(args) != 1 {
-		log.Print(filepath.Base(os.Args[0]) + " [args...] movfile")
-		flag.PrintDefaults()
-		log.Println("Results will be printed following order regardless of the flag order given by user: ")
-		log.Println("\tstart, end, duration, resolution")
+	if watch {
+		if watchInterval <= 0 {
+			log.Fatalf("-watch-interval should be positive: %v", watchInterval)
+		}
+		if since != "" {
+			log.Fatal("-since cannot be used with -watch")
+		}
+		// a mov after the directory would never be probed.
+		if len(args) > 1 {
+			log.Fatal("-watch needs a directory only")
+		}
+	}
+	if fromFile != "" {
+		if watch {
+			log.Fatal("-from-file cannot be used with -watch")
+		}
+		files, err := loadFileList(fromFile)
+		if err != nil {
+			log.Fatalf("-from-file: %v", err)
+		}
+		if len(files) == 0 {
+			log.Fatalf("-from-file: no paths in %v", fromFile)
+		}
+		args = append(args, files...)
+	}
+	if cfg.seconds && cfg.midnightFrames {
+		log.Fatal("-seconds and -frames-from-midnight cannot be used together")
+	}
+	if cfg.rounding != roundHalfUp && cfg.rounding != roundFloor {
+		log.Fatalf("unknown rounding mode: %v", cfg.rounding)
+	}
+	if cfg.dropMode != dropAuto && cfg.dropMode != dropOn && cfg.dropMode != dropOff {
+		log.Fatalf("unknown drop mode: %v", cfg.dropMode)
+	}
+	if _, err := loadZone(cfg.timezone); err != nil {
+		log.Fatalf("-timezone: %v", err)
+	}
+	if cfg.endRate != endRateTimecode && cfg.endRate != endRateVideo {
+		log.Fatalf("unknown -end-rate: %v", cfg.endRate)
+	}
+	if _, ok := lookupRefRate(cfg.refRate); cfg.refRate != "" && !ok {
+		log.Fatalf("unsupported -ref-rate: %v", cfg.refRate)
+	}
+	if _, err := parseTimecodeOrder(cfg.timecodeOrder); err != nil {
+		log.Fatalf("invalid -timecode-order: %v", err)
+	}
+	if _, ok := standards[cfg.standard]; cfg.standard != "" && !ok {
+		log.Fatalf("unknown standard: %v", cfg.standard)
+	}
+	for _, r := range []struct {
+		s     string
+		class *string
+	}{{minResolution, &cfg.limits.minClass}, {maxResolution, &cfg.limits.maxClass}} {
+		if r.s == "" {
+			continue
+		}
+		class, err := parseLimitClass(r.s)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*r.class = class
+	}
+	if all && cfg.limits.active() {
+		log.Fatal("-all cannot be used with -min-duration, -max-duration, -min-resolution and -max-resolution")
+	}
+	order := []string{}
+	if fieldOrder != "" {
+		var err error
+		order, err = parseFieldNames(fieldOrder)
+		if err != nil {
+			log.Fatalf("invalid -order: %v", err)
+		}
+	}
+	if groupBy != "" && (watch || sidecarOut || hook != "") {
+		log.Fatal("-group-by cannot be used with -watch, -sidecar and -exec")
+	}
+	w := openOutput(outPath)
+	defer closeOutput(w)
+	if sequence && len(args) > 0 {
+		if len(args) < 2 {
+			log.Fatal("-sequence needs two or more movs")
+		}
+		gaps, err := checkSequence(args, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := printSequence(w, gaps, jsonOut); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
-	file := args[0]
-	ext := filepath.Ext(file)
-	if ext != "" {
-		// remove dot(.)
-		ext = ext[1:]
+	if segments && len(args) > 0 {
+		if len(args) < 2 {
+			log.Fatal("-segments needs two or more movs")
+		}
+		line, err := checkSegments(args, cfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintln(w, line)
+		return
 	}
-	if !cfg.start && !cfg.end && !cfg.duration && !cfg.fps && !cfg.resolution && !cfg.codec && !cfg.colorspace {
-		log.Fatalf("need to set at least one of -start, -end, -duration, -fps, -resolution, -codec, -colorspace flag")
+	if diffMode && len(args) > 0 {
+		if len(args) != 2 {
+			log.Fatal("-diff needs two movs")
+		}
+		ignored := []string{}
+		if ignore != "" {
+			var err error
+			ignored, err = parseFieldNames(ignore)
+			if err != nil {
+				log.Fatalf("invalid -ignore: %v", err)
+			}
+		}
+		diffs, err := diffMovs(args[0], args[1], cfg, ignored)
+		if err != nil {
+			log.Fatal(err)
+		}
+		color, err := useColor(colorMode, w, jsonOut)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := printDiff(w, diffs, jsonOut, color); err != nil {
+			log.Fatal(err)
+		}
+		for _, d := range diffs {
+			if d.differs() {
+				closeOutput(w)
+				os.Exit(3)
+			}
+		}
+		return
 	}
-
-	c := exec.Command("ffprobe", "-show_streams", file)
-	b, err := c.CombinedOutput()
-	if err != nil {
-		log.Fatalf("failed to execute: %s", b)
+	if setTC != "" && len(args) > 0 {
+		if len(args) != 1 {
+			log.Fatal("-set-timecode needs a mov")
+		}
+		if dry {
+			if err := checkSetTimecodeOut(args[0], setTCOut); err != nil {
+				log.Fatal(err)
+			}
+			// a relative timecode isn't known until the mov is probed.
+			tc := setTC
+			if strings.HasPrefix(tc, "+") {
+				tc = "..."
+			}
+			for _, l := range dryRun(args[0], setTimecodeConfig(cfg, setTC), false) {
+				fmt.Println(l)
+			}
+			// the tmcd tracks aren't known either.
+			fmt.Println("LC_ALL=C " + quoteArgv(setTimecodeCommand(cfg.ffmpeg, args[0], setTCOut, tc, []string{"..."}, force)))
+			return
+		}
+		tc, err := setTimecode(args[0], setTCOut, setTC, cfg, force)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Fprintf(w, "%v\t%v\n", setTCOut, tc)
+		return
 	}
-	out := string(b)
-	res, err := parse(out, cfg)
+	if len(args) == 0 {
+		log.Print(filepath.Base(os.Args[0]) + " [args...] movfile...")
+		log.Print(filepath.Base(os.Args[0]) + " -sequence [args...] movfile movfile...")
+		log.Print(filepath.Base(os.Args[0]) + " -segments [args...] movfile movfile...")
+		log.Print(filepath.Base(os.Args[0]) + " -diff [args...] movfile movfile")
+		log.Print(filepath.Base(os.Args[0]) + " -watch [args...] dir")
+		log.Print(filepath.Base(os.Args[0]) + " -set-timecode timecode -set-timecode-out out.mov [args...] movfile")
+		log.Print(filepath.Base(os.Args[0]) + " -from-file list [args...]")
+		printUsage(flag.CommandLine.Output(), flag.CommandLine, "")
+		log.Printf("Flags of a group could be printed with -help=group. (%v)", strings.Join(groupNames(), ", "))
+		log.Printf("Default flags could be set with %v environment variable. Flags in the command line override them.", defaultsEnv)
+		log.Println("Results will be printed following order regardless of the flag order given by user, unless -order is given. -order and -ignore take these names: ")
+		log.Println("\t" + strings.Join(fieldNames(), ", "))
+		return
+	}
+	if failFast && keepGoing {
+		log.Fatal("-fail-fast and -continue cannot be used together")
+	}
+	if dry {
+		for _, file := range args {
+			for _, l := range dryRun(file, cfg, all) {
+				fmt.Println(l)
+			}
+		}
+		return
+	}
+	fail := func(file string, err error) {
+		if jsonOut {
+			writeJSONError(file, err)
+		} else if len(args) > 1 || watch {
+			log.Printf("%v: %v", file, err)
+		} else {
+			log.Print(err)
+		}
+	}
+	if !all && !sidecarOut && !cfg.requested() {
+		fail(args[0], fmt.Errorf("%w: %v", ErrNoFlag, fieldFlagNames()))
+		os.Exit(1)
+	}
+	color, err := useColor(colorMode, w, jsonOut || compact)
 	if err != nil {
 		log.Fatal(err)
 	}
-	if res.start != "" {
-		fmt.Println(res.start)
+	outliers := 0
+	out := output{w: w, all: all, hook: hook, compact: compact, json: jsonOut, color: color, outliers: &outliers, order: order}
+	if watch {
+		// json has the file in it.
+		out.header = !jsonOut
+		err := watchDir(args[0], watchInterval, func(file string) {
+			if sidecarOut {
+				if err := writeSidecarOf(w, file, cfg, force); err != nil {
+					fail(file, err)
+				}
+				return
+			}
+			if err := report(file, cfg, out); err != nil {
+				// keep watching for the other movs.
+				fail(file, err)
+			}
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	sinceTime := time.Time{}
+	if since != "" {
+		var err error
+		sinceTime, err = parseSince(since, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	// json has the file in it.
+	out.header = len(args) > 1 && !jsonOut
+	if groupBy != "" {
+		out.groups = newGroups(groupBy)
+	}
+	failed := runBatch(args, failFast, func(file string) error {
+		if since != "" {
+			ok, err := modifiedSince(file, sinceTime)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				log.Printf("skipped %v: not modified since %v", file, sinceTime.Format(time.RFC3339))
+				return nil
+			}
+		}
+		if sidecarOut {
+			return writeSidecarOf(w, file, cfg, force)
+		}
+		return report(file, cfg, out)
+	}, fail)
+	if out.groups != nil {
+		if err := out.groups.print(w, jsonOut); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(failed) != 0 {
+		if len(args) > 1 {
+			log.Printf("%v of %v movs failed", len(failed), len(args))
+		}
+		closeOutput(w)
+		os.Exit(1)
+	}
+	if outliers != 0 {
+		if len(args) > 1 {
+			log.Printf("%v of %v movs are outliers", outliers, len(args))
+		}
+		closeOutput(w)
+		os.Exit(3)
+	}
+}
+
+// output is how results are printed.
+type output struct {
+	w       io.Writer
+	all     bool
+	hook    string
+	compact bool
+	json    bool
+	// color colors the fields with ANSI escape codes for a terminal.
+	color bool
+	// header prints the file name before its result.
+	header bool
+	// outliers counts movs that don't meet limits of the config.
+	outliers *int
+	// groups collects the movs for -group-by, instead of printing them.
+	groups *groups
+	// order is field names of -order to print first.
+	order []string
+}
+
+// report probes the file for cfg, and prints the result as out says.
+func report(file string, cfg config, out output) error {
+	var res result
+	if out.all {
+		info, err := probeAll(file, cfg)
+		if err != nil {
+			return err
+		}
+		res = info.result()
+	} else {
+		var err error
+		res, err = probeFile(file, cfg)
+		if err != nil {
+			return err
+		}
+	}
+	warn(res.warnings)
+	if cfg.limits.active() {
+		if res.outlier == "" {
+			return nil
+		}
+		if out.outliers != nil {
+			*out.outliers++
+		}
+	}
+	if out.groups != nil {
+		return out.groups.add(file, res.fields())
+	}
+	if out.header {
+		fmt.Fprintln(out.w, file)
+	}
+	flds := orderFields(res.fields(), out.order)
+	// the sources are only written after the values for people. in json they are
+	// an object of their own, so that the values stay as they are.
+	sources := fieldSources(flds, res.sources)
+	if out.hook != "" {
+		m := map[string]any{"file": file}
+		for _, f := range flds {
+			m[f.name] = f.value
+		}
+		for _, f := range res.detailFields() {
+			m[f.name] = f.value
+		}
+		m, err := runHook(out.hook, m)
+		if err != nil {
+			return err
+		}
+		if out.json {
+			if len(sources) != 0 {
+				m["sources"] = sources
+			}
+			b, err := json.Marshal(m)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out.w, string(b))
+			return nil
+		}
+		hooked := hookedFields(m, flds)
+		if !out.compact {
+			hooked = explainFields(hooked, sources)
+		}
+		if out.color {
+			hooked = colorFields(hooked)
+		}
+		printFields(out.w, hooked, out.compact)
+		return nil
+	}
+	if out.json {
+		m := map[string]any{"file": file}
+		for _, f := range flds {
+			m[f.name] = f.value
+		}
+		for _, f := range res.detailFields() {
+			m[f.name] = f.value
+		}
+		if len(sources) != 0 {
+			m["sources"] = sources
+		}
+		b, err := json.Marshal(m)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out.w, string(b))
+		return nil
+	}
+	if !out.compact {
+		flds = explainFields(flds, sources)
+	}
+	if out.color {
+		flds = colorFields(flds)
 	}
-	if res.end != "" {
-		fmt.Println(res.end)
+	printFields(out.w, flds, out.compact)
+	return nil
+}
+
+// fieldSources returns sources of the fields that have one, or nil when none has.
+func fieldSources(flds []field, sources map[string]string) map[string]string {
+	var m map[string]string
+	for _, f := range flds {
+		if src := sources[f.name]; src != "" {
+			if m == nil {
+				m = map[string]string{}
+			}
+			m[f.name] = src
+		}
+	}
+	return m
+}
+
+// explainFields adds sources of the fields after their values. (ex. 102 (nb_frames))
+// Fields without a source are unchanged.
+func explainFields(flds []field, sources map[string]string) []field {
+	explained := make([]field, 0, len(flds))
+	for _, f := range flds {
+		if src := sources[f.name]; src != "" {
+			f.value += " (" + src + ")"
+		}
+		explained = append(explained, f)
+	}
+	return explained
+}
+
+// argsFlag is a repeatable flag, that collects its values in s.
+type argsFlag struct {
+	s *[]string
+}
+
+func (f argsFlag) String() string {
+	if f.s == nil {
+		return ""
+	}
+	return strings.Join(*f.s, " ")
+}
+
+func (f argsFlag) Set(v string) error {
+	*f.s = append(*f.s, v)
+	return nil
+}
+
+// printFields prints values of the fields to w line by line,
+// or in a single line of key=value pairs when compact is true.
+func printFields(w io.Writer, flds []field, compact bool) {
+	if compact {
+		fmt.Fprintln(w, compactLine(flds))
+		return
+	}
+	for _, f := range flds {
+		fmt.Fprintln(w, f.value)
+	}
+}
+
+// nopCloser is a writer that doesn't close, for stdout.
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// outputFile is the file of -o. It is created or truncated at the first write,
+// so that the file is left as it is when nothing is printed, like the usage.
+// Close returns the first error of creating or writing it.
+type outputFile struct {
+	path string
+	f    *os.File
+	err  error
+}
+
+func (o *outputFile) Write(p []byte) (int, error) {
+	if o.f == nil && o.err == nil {
+		o.f, o.err = os.Create(o.path)
+	}
+	if o.err != nil {
+		return 0, o.err
+	}
+	n, err := o.f.Write(p)
+	if err != nil {
+		o.err = err
+	}
+	return n, err
+}
+
+func (o *outputFile) Close() error {
+	if o.f == nil {
+		return o.err
 	}
-	if res.duration != "" {
-		fmt.Println(res.duration)
+	err := o.f.Close()
+	if o.err != nil {
+		return o.err
 	}
-	if res.fps != "" {
-		fmt.Println(res.fps)
+	return err
+}
+
+// openOutput returns the writer for results at path, that is an outputFile.
+// It returns stdout when path is empty or "-".
+func openOutput(path string) io.WriteCloser {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}
 	}
-	if res.resolution != "" {
-		fmt.Println(res.resolution)
+	return &outputFile{path: path}
+}
+
+// closeOutput closes the writer of results, and fails when they couldn't be written.
+func closeOutput(w io.Closer) {
+	if err := w.Close(); err != nil {
+		log.Fatalf("couldn't write the results: %v", err)
 	}
-	if res.codec != "" {
-		fmt.Println(res.codec)
+}
+
+// compactLine formats fields as space separated key=value pairs.
+// Values that have spaces or special characters are quoted.
+func compactLine(flds []field) string {
+	pairs := make([]string, 0, len(flds))
+	for _, f := range flds {
+		v := f.value
+		if v == "" || strings.ContainsAny(v, " \t\n\"=\\") {
+			v = strconv.Quote(v)
+		}
+		pairs = append(pairs, f.name+"="+v)
 	}
-	if res.colorspace != "" {
-		fmt.Println(res.colorspace)
+	return strings.Join(pairs, " ")
+}
+
+// warn prints warnings to stderr.
+func warn(warnings []string) {
+	for _, w := range warnings {
+		log.Print("warning: " + w)
 	}
 }
 
@@ -187,48 +1187,65 @@ func main() {
 func parse(data string, cfg config) (res result, err error) {
 	idx := strings.Index(data, "[STREAM]")
 	if idx == -1 {
-		return res, fmt.Errorf("cannot find [STREAM] lines")
+		return res, noStreamError(data)
 	}
 	overview := data[:idx]
 	streamData := data[idx:]
+	formatTags := parseFormatTags(overview)
 	fps := ""
+	// fpsSource is where fps came from, for -explain.
+	fpsSource := ""
 	videoIdx := -1
-	for _, l := range strings.Split(overview, "\n") {
+	// videoLine is the stream line of the video in the overview.
+	videoLine := ""
+	for r := (lineReader{s: overview}); r.next(); {
+		l := r.line
 		l = strings.TrimSpace(l)
 		if strings.HasPrefix(l, "Stream #0:") && fps == "" {
 			flds := strings.Fields(l)
-			if flds[2] != "Video:" {
+			if flds[2] != "Video:" || strings.HasSuffix(l, "(attached pic)") {
 				continue
 			}
 			rest := strings.TrimPrefix(l, "Stream #0:")
 			if len(rest) == 0 {
-				return res, fmt.Errorf("unexpected stream line")
+				return res, ErrStreamLine
 			}
 			trimDigits := strings.TrimLeft(rest, "0123456789")
 			n := rest[:len(rest)-len(trimDigits)]
 			videoIdx, err = strconv.Atoi(n)
 			if err != nil {
-				return res, fmt.Errorf("unexpected stream line")
+				return res, ErrStreamLine
 			}
+			videoLine = l
 			idx := -1
-			for i, f := range flds {
-				if f == "fps" || f == "fps," {
-					idx = i
+			// some streams only have tbr, which is the guessed frame rate.
+			for _, unit := range []string{"fps", "tbr"} {
+				for i, f := range flds {
+					if f == unit || f == unit+"," {
+						idx = i
+						fpsSource = unit + " of the overview"
+						break
+					}
+				}
+				if idx != -1 {
 					break
 				}
 			}
 			if idx == -1 {
 				continue
 			}
-			fps = flds[idx-1]
+			fps = dotDecimal(flds[idx-1])
 		}
 	}
 	if videoIdx == -1 {
-		return res, fmt.Errorf("not found video stream")
+		return res, ErrNoVideoStream
+	}
+	if err := checkTruncated(overview, streamData); err != nil {
+		return res, err
 	}
 	streams := strings.SplitAfter(streamData, "[/STREAM]")
 	if videoIdx >= len(streams) {
-		return res, fmt.Errorf("unmatched video stream")
+		return res, ErrUnmatchedStream
 	}
 	frames := 0
 	width := ""
@@ -237,16 +1254,38 @@ func parse(data string, cfg config) (res result, err error) {
 	codec := ""
 	codec_profile := ""
 	pix_fmt := ""
+	colorRange := ""
+	sar := ""
+	level := ""
+	encoder := ""
+	creationTime := ""
 	colorspace := ""
+	readFrames := 0
+	// zeroFrames is whether nb_frames is 0, rather than missing.
+	zeroFrames := false
+	videoRate := ""
+	fieldOrder := ""
+	duration := ""
+	startTime := ""
+	startPts := ""
+	timeBase := ""
 	videoStream := streams[videoIdx]
-	for _, l := range strings.Split(videoStream, "\n") {
+	for r := (lineReader{s: videoStream}); r.next(); {
+		l := r.line
 		if fps != "" && timecode != "" && frames != 0 {
 			break
 		}
-		if strings.HasPrefix(l, "nb_frames=") && frames == 0 {
+		if strings.HasPrefix(l, "nb_frames=") && frames == 0 && l != "nb_frames=N/A" {
 			frames, err = strconv.Atoi(strings.TrimPrefix(l, "nb_frames="))
 			if err != nil {
-				return res, fmt.Errorf("invalid frames: %v", l)
+				return res, fmt.Errorf("%w: %v", ErrInvalidFrames, l)
+			}
+			zeroFrames = frames == 0
+		}
+		if strings.HasPrefix(l, "nb_read_frames=") && readFrames == 0 && l != "nb_read_frames=N/A" {
+			readFrames, err = strconv.Atoi(strings.TrimPrefix(l, "nb_read_frames="))
+			if err != nil {
+				return res, fmt.Errorf("%w: %v", ErrInvalidFrames, l)
 			}
 		}
 		if strings.HasPrefix(l, "width=") && width == "" {
@@ -261,77 +1300,1480 @@ func parse(data string, cfg config) (res result, err error) {
 		if strings.HasPrefix(l, "profile=") && codec_profile == "" {
 			codec_profile = strings.TrimPrefix(l, "profile=")
 		}
+		if strings.HasPrefix(l, "TAG:encoder=") && encoder == "" {
+			encoder = strings.TrimPrefix(l, "TAG:encoder=")
+		}
+		if strings.HasPrefix(l, "TAG:creation_time=") && creationTime == "" {
+			creationTime = strings.TrimPrefix(l, "TAG:creation_time=")
+		}
+		if strings.HasPrefix(l, "level=") && level == "" {
+			level = strings.TrimPrefix(l, "level=")
+		}
 		if strings.HasPrefix(l, "pix_fmt=") && pix_fmt == "" {
 			pix_fmt = strings.TrimPrefix(l, "pix_fmt=")
 		}
+		if strings.HasPrefix(l, "sample_aspect_ratio=") && sar == "" {
+			sar = strings.TrimPrefix(l, "sample_aspect_ratio=")
+		}
+		if strings.HasPrefix(l, "color_range=") && colorRange == "" {
+			colorRange = strings.TrimPrefix(l, "color_range=")
+		}
 		if strings.HasPrefix(l, "color_space=") && colorspace == "" {
 			colorspace = strings.TrimPrefix(l, "color_space=")
 		}
+		if strings.HasPrefix(l, "r_frame_rate=") && videoRate == "" {
+			videoRate = strings.TrimPrefix(l, "r_frame_rate=")
+		}
+		if strings.HasPrefix(l, "field_order=") && fieldOrder == "" {
+			fieldOrder = strings.TrimPrefix(l, "field_order=")
+		}
+		if strings.HasPrefix(l, "duration=") && duration == "" {
+			duration = dotDecimal(strings.TrimPrefix(l, "duration="))
+		}
+		if strings.HasPrefix(l, "start_time=") && startTime == "" {
+			startTime = dotDecimal(strings.TrimPrefix(l, "start_time="))
+		}
+		if strings.HasPrefix(l, "start_pts=") && startPts == "" {
+			startPts = strings.TrimPrefix(l, "start_pts=")
+		}
+		if strings.HasPrefix(l, "time_base=") && timeBase == "" {
+			timeBase = strings.TrimPrefix(l, "time_base=")
+		}
 		if strings.HasPrefix(l, "TAG:timecode=") {
-			timecode = strings.TrimPrefix(l, "TAG:timecode=")
-			if len(timecode) != 11 {
-				return res, fmt.Errorf("invalid timecode: %v", l)
+			timecode = cleanTimecodeTag(strings.TrimPrefix(l, "TAG:timecode="), cfg.strictTimecodeLength)
+			if len(strings.TrimPrefix(timecode, "-")) != 11 {
+				return res, fmt.Errorf("%w: %v", ErrInvalidTimecode, l)
 			}
 		}
 	}
-	if cfg.start {
-		if timecode == "" {
-			return res, fmt.Errorf("missing TAG:timecode information")
+	if fps == "" {
+		// r_frame_rate isn't preferred to the overview, as it could be
+		// the field rate of interlaced video.
+		if r, err := parseRate(videoRate); err == nil && r > 0 {
+			fps = formatFPS(r)
+			fpsSource = "r_frame_rate"
 		}
-		res.start = timecode
 	}
-	if cfg.end {
-		if timecode == "" {
-			return res, fmt.Errorf("missing TAG:timecode information")
+	if cfg.bitrate {
+		if d, err := strconv.ParseFloat(duration, 64); err == nil {
+			res.length = d
 		}
-		if fps == "" {
-			return res, fmt.Errorf("missing fps information")
+	}
+	// candidates are the timecode tags of the sources of -timecode-order.
+	candidates := []struct {
+		source   string
+		timecode string
+		stream   int
+	}{
+		{tcVideo, timecode, videoIdx},
+		{tcStreams, "", -1},
+		{tcFormat, cleanTimecodeTag(formatTags["timecode"], cfg.strictTimecodeLength), -1},
+	}
+	// timecode could be only in other stream, ex) tmcd track.
+	for i, stream := range streams {
+		if i == videoIdx {
+			continue
 		}
-		if frames == 0 {
-			return res, fmt.Errorf("missing nb_frames information")
+		for r := (lineReader{s: stream}); r.next(); {
+			if strings.HasPrefix(r.line, "TAG:timecode=") {
+				candidates[1].timecode = cleanTimecodeTag(strings.TrimPrefix(r.line, "TAG:timecode="), cfg.strictTimecodeLength)
+				break
+			}
+		}
+		if candidates[1].timecode != "" {
+			candidates[1].stream = i
+			break
+		}
+	}
+	timecode = ""
+	tcFrom := ""
+	tcStream := -1
+	for _, src := range cfg.timecodeSources() {
+		for _, c := range candidates {
+			if c.source != src || c.timecode == "" || timecode != "" {
+				continue
+			}
+			if len(strings.TrimPrefix(c.timecode, "-")) != 11 {
+				return res, fmt.Errorf("%w: %v", ErrInvalidTimecode, c.timecode)
+			}
+			timecode = c.timecode
+			tcFrom = c.source
+			tcStream = c.stream
+		}
+	}
+	fromFormat := tcFrom == tcFormat
+	if timecode != "" {
+		// name is how a source is called in warnings.
+		name := func(source string, stream int) string {
+			if source == tcFormat {
+				return "the format"
+			}
+			return fmt.Sprintf("stream %v", stream)
+		}
+		for _, c := range candidates {
+			if c.source != tcFrom && c.timecode != "" && c.timecode != timecode {
+				res.warnings = append(res.warnings, fmt.Sprintf("timecode of %v %v differs from timecode of %v %v, using %v's", name(c.source, c.stream), c.timecode, name(tcFrom, tcStream), timecode, name(tcFrom, tcStream)))
+			}
+		}
+	}
+	if cfg.timecodeSource {
+		switch {
+		case tcFrom != "":
+			res.timecodeSource = tcFrom
+		case cfg.startFrom != "" && cfg.startFrom[0] != '+':
+			res.timecodeSource = "-start-from"
+		default:
+			return res, ErrMissingTimecode
+		}
+	}
+	if cfg.timecodeStream {
+		if fromFormat {
+			res.timecodeStream = "format"
+		} else if tcStream == -1 {
+			return res, ErrMissingTimecode
+		} else {
+			res.timecodeStream = strconv.Itoa(tcStream)
+		}
+	}
+	if cfg.timecodes {
+		res.timecodes = parseTimecodes(streams)
+		if res.timecodes == "" {
+			return res, ErrMissingTimecode
+		}
+	}
+	if cfg.strict {
+		if _, ok := LookupFrameRate(videoRate); !ok {
+			if _, ok := lookupFPS(fps); !ok {
+				return res, fmt.Errorf("%w: %v (%v)", ErrUnsupportedFPS, fps, videoRate)
+			}
+		}
+	}
+	framesSource := "nb_frames"
+	if cfg.countFrames {
+		// frames counted by decoding are authoritative.
+		if readFrames != 0 {
+			frames = readFrames
+			framesSource = "nb_read_frames"
+		} else if frames != 0 {
+			res.warnings = append(res.warnings, "ffprobe couldn't count frames, using nb_frames instead")
+		} else {
+			return res, fmt.Errorf("%w: ffprobe couldn't count frames either", ErrMissingFrames)
+		}
+	}
+	if _, err := strconv.ParseFloat(duration, 64); frames == 0 && !zeroFrames && !cfg.countFrames && err != nil && cfg.needsFrames() {
+		// neither nb_frames nor duration of the stream, but the overview could have one.
+		if d, ok := parseOverviewDuration(overview); ok {
+			rate, err := parseRate(videoRate)
+			if err != nil {
+				rate, err = strconv.ParseFloat(fps, 64)
+			}
+			if err == nil {
+				frames = cfg.roundFrames(d * rate)
+				framesSource = "Duration of the overview * rate"
+				res.warnings = append(res.warnings, fmt.Sprintf("nb_frames and duration of the video are missing, using Duration of the overview for %v frames, which is only in 1/100 seconds", frames))
+			}
+		}
+	}
+	if frames == 0 && !cfg.countFrames && isImage2(overview) {
+		// image2 doesn't count frames, but its duration is exactly frames / rate.
+		if n, _ := computedFrames(0, duration, videoRate, cfg); n > 0 {
+			frames = n
+			framesSource = "duration * rate of image sequence"
+		}
+	}
+	if n, ok := fieldsToFrames(frames, fieldOrder, duration, videoRate); ok {
+		res.warnings = append(res.warnings, fmt.Sprintf("nb_frames %v seems to count fields of interlaced video, corrected to %v", frames, n))
+		frames = n
+		framesSource = "nb_frames of fields / 2"
+	} else if frames != 0 && !(cfg.countFrames && readFrames != 0) {
+		if n, ok := computedFrames(frames, duration, videoRate, cfg); !ok {
+			res.framesDiff = frames - n
+			if cfg.computedFrames {
+				res.warnings = append(res.warnings, fmt.Sprintf("nb_frames %v doesn't match duration * rate %v, using %v", frames, n, n))
+				frames = n
+				framesSource = "duration * rate"
+			} else {
+				res.warnings = append(res.warnings, fmt.Sprintf("nb_frames %v doesn't match duration * rate %v", frames, n))
+			}
+		}
+	}
+	// noFrames returns the error for a field that needs number of frames, when it is 0.
+	// A mov without frames fails clearly, instead of having an end before its start.
+	// nb_frames 0 with a duration is taken as missing, as some muxers write 0 for unknown.
+	noFrames := func() error {
+		if d, err := strconv.ParseFloat(duration, 64); zeroFrames && frames == 0 && (err != nil || d == 0) {
+			return fmt.Errorf("%w: nb_frames is 0", ErrNoFrames)
+		}
+		return ErrMissingFrames
+	}
+	tmcd := findTmcd(streams)
+	tcRateProblem := checkTimecodeRate(tmcd, videoRate, fps)
+	if tcRateProblem != "" && cfg.endRate == endRateVideo {
+		// count in the rate of the video, as if the tmcd track had it. Timecode of
+		// a fraction of the rate, like 29.97 of 59.94 video, isn't a problem to fix.
+		tmcd.rate = 0
+	}
+	// timecodeRate returns the real frame rate of the timecode, for converting it to seconds.
+	// tmcd track often has the nominal rate (ex. 24/1 for 23.976), so it follows the video's.
+	timecodeRate := func() (float64, error) {
+		if ref, ok := lookupRefRate(cfg.refRate); ok {
+			return ref.Float(), nil
+		}
+		rate, err := parseRate(videoRate)
+		if err != nil {
+			rate, err = strconv.ParseFloat(fps, 64)
+			if err != nil {
+				return 0, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+			}
+		}
+		if tmcd.rate != 0 && math.Round(tmcd.rate) != math.Round(rate) {
+			// ex) 29.97 for timecode of 59.94 video.
+			rate = rate * math.Round(tmcd.rate) / math.Round(rate)
+		}
+		return rate, nil
+	}
+	// startShift is frames the video starts after time zero of the mov, that the timecode
+	// tag is for. It is only used with -shift-start, as start_time of a couple of frames
+	// is usual for B-frame delay or an edit list, and the tag is meant for the first frame.
+	startShift := 0
+	if cfg.shiftStart {
+		if off := startOffset(startTime, startPts, timeBase); off > 0 {
+			if rate, err := timecodeRate(); err == nil {
+				startShift = int(math.Round(off * rate))
+			}
+			if startShift != 0 {
+				res.warnings = append(res.warnings, fmt.Sprintf("video starts at %v seconds, start and end are shifted by %v frames", formatSeconds(off), startShift))
+			}
+		}
+	}
+	// newStart creates start Timecode and returns it with number of frames in the timecode's rate.
+	newStart := func() (*Timecode, int, error) {
+		if timecode == "" && (cfg.startFrom == "" || cfg.startFrom[0] == '+') {
+			return nil, 0, ErrMissingTimecode
+		}
+		if fps == "" {
+			return nil, 0, ErrMissingFPS
+		}
+		var base int
+		var drop bool
+		tcFrames := frames
+		if cfg.refRate != "" {
+			ref, ok := lookupRefRate(cfg.refRate)
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: %v (-ref-rate)", ErrUnsupportedFPS, cfg.refRate)
+			}
+			vr, err := parseRate(videoRate)
+			if err != nil {
+				vr, err = strconv.ParseFloat(fps, 64)
+				if err != nil {
+					return nil, 0, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+				}
+			}
+			// the mov lasts as long in the reference rate, count its frames in it.
+			base = ref.Base
+			drop = ref.Drop
+			tcFrames = cfg.roundFrames(float64(frames) * ref.Float() / vr)
+		} else if tmcd.rate > 0 {
+			// timecode track has its own rate, which could differ from the video's.
+			base = int(math.Round(tmcd.rate))
+			if !knownBase(base) {
+				return nil, 0, fmt.Errorf("%w: %v (tmcd)", ErrUnsupportedFPS, tmcd.rate)
+			}
+			drop = tmcd.drop
+			if vr, err := parseRate(videoRate); err == nil {
+				// timecode counts frames in its nominal rate, convert video frames to it.
+				vbase := int(math.Round(vr))
+				if vbase != base {
+					tcFrames = cfg.roundFrames(float64(frames) * float64(base) / float64(vbase))
+				}
+			}
+		} else {
+			rate, ok := LookupFrameRate(videoRate)
+			if !ok {
+				rate, ok = lookupFPS(fps)
+			}
+			if !ok {
+				return nil, 0, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+			}
+			base = rate.Base
+			drop = rate.Drop
+		}
+		switch cfg.dropMode {
+		case dropOn:
+			// only bases of 30 and 60 have drop frame, 23.976 has -force-drop.
+			drop = base%30 == 0
+		case dropOff:
+			drop = false
+		}
+		if base == 24 {
+			drop = cfg.forceDrop
+		}
+		code := timecode
+		offset := 0
+		if cfg.startFrom != "" {
+			from := strings.TrimPrefix(cfg.startFrom, "+")
+			if err := validateTimecode(from, base, drop); err != nil {
+				name := cfg.startFromFlag
+				if name == "" {
+					name = "-start-from"
+				}
+				return nil, 0, fmt.Errorf("%v: %w", name, err)
+			}
+			if from == cfg.startFrom {
+				code = from
+			} else {
+				// offset is a duration, count it in non-drop.
+				off, err := NewTimecode(from, base, false)
+				if err != nil {
+					return nil, 0, err
+				}
+				offset = off.Frames()
+			}
+		}
+		var tc *Timecode
+		var err error
+		if base == 24 && drop {
+			tc, err = NewTimecodeForceDrop(code, base)
+		} else {
+			tc, err = NewTimecode(code, base, drop)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if from := cfg.startFrom; from == "" || from[0] == '+' {
+			// the shift is of the timecode tag, not of -start-from that is the first frame.
+			offset += startShift
+		}
+		tc.Add(offset)
+		return tc, tcFrames, nil
+	}
+	// lastFrame returns timecode of the last frame of the mov, or the frame after it when exclusive.
+	lastFrame := func(exclusive bool) (*Timecode, error) {
+		tc, tcFrames, err := newStart()
+		if err != nil {
+			return nil, err
+		}
+		if tcFrames == 0 {
+			return nil, noFrames()
+		}
+		n := tcFrames - 1
+		if exclusive {
+			n = tcFrames
+		}
+		// a clip could cross midnight, or zero from a negative start of pre-roll,
+		// that the timecode wraps.
+		tc.Add(n)
+		return tc, nil
+	}
+	if cfg.start {
+		if cfg.computedStart() {
+			tc, _, err := newStart()
+			if err != nil {
+				return res, err
+			}
+			res.start = cfg.formatTimecode(tc)
+			if cfg.seconds {
+				rate, err := timecodeRate()
+				if err != nil {
+					return res, err
+				}
+				res.start = formatSeconds(tc.Seconds(rate))
+			}
+			if cfg.midnightFrames {
+				res.start = strconv.Itoa(tc.Frames())
+			}
+		} else {
+			if timecode == "" {
+				return res, ErrMissingTimecode
+			}
+			res.start = timecode
+		}
+	}
+	if cfg.end {
+		tc, err := lastFrame(cfg.exclusiveEnd)
+		if err != nil {
+			return res, err
+		}
+		if tc.PastMidnight() {
+			res.warnings = append(res.warnings, fmt.Sprintf("the mov crosses midnight, end %v is of the next day", cfg.formatTimecode(tc)))
+		}
+		if tcRateProblem != "" && cfg.refRate == "" {
+			governs := "the timecode"
+			if cfg.endRate == endRateVideo {
+				governs = "the video"
+			}
+			res.warnings = append(res.warnings, fmt.Sprintf("%v, end is counted in the rate of %v (-end-rate)", tcRateProblem, governs))
+		}
+		res.end = cfg.formatTimecode(tc)
+		res.base = tc.Base()
+		if cfg.seconds {
+			rate, err := timecodeRate()
+			if err != nil {
+				return res, err
+			}
+			res.end = formatSeconds(tc.Seconds(rate))
+		}
+		if cfg.midnightFrames {
+			res.end = strconv.Itoa(tc.Frames())
+		}
+	}
+	if cfg.detelecineEnd {
+		tc, tcFrames, err := newStart()
+		if err != nil {
+			return res, err
+		}
+		if tcFrames == 0 {
+			return res, noFrames()
+		}
+		end := *tc
+		end.Add(tcFrames - 1)
+		res.telecine.end = cfg.formatTimecode(&end)
+		if tc.Base() == 30 {
+			film, err := filmEnd(tc, tcFrames, cfg)
+			if err != nil {
+				return res, err
+			}
+			res.telecine.filmEnd = cfg.formatTimecode(film)
+		} else {
+			res.telecine.rate = fps
+		}
+	}
+	if cfg.samples > 0 {
+		tc, tcFrames, err := newStart()
+		if err != nil {
+			return res, err
+		}
+		if tcFrames == 0 {
+			return res, noFrames()
+		}
+		// seconds should be in the rate of the timecode.
+		rate, err := timecodeRate()
+		if err != nil {
+			return res, err
+		}
+		res.samples = cfg.samplePoints(tc, tcFrames, cfg.samples, rate)
+	}
+	if cfg.quarters {
+		tc, tcFrames, err := newStart()
+		if err != nil {
+			return res, err
+		}
+		if tcFrames == 0 {
+			return res, noFrames()
+		}
+		res.quarterPoints = cfg.quarterPoints(tc, tcFrames)
+		lines := make([]string, 0, len(quarterPercents))
+		for i, p := range quarterPercents {
+			lines = append(lines, fmt.Sprintf("%v%%\t%v", p, res.quarterPoints[i]))
+		}
+		res.quarters = strings.Join(lines, "\n")
+	}
+	if cfg.checkTimecodeRate {
+		res.timecodeRateCheck = "ok"
+		if tcRateProblem != "" {
+			res.timecodeRateCheck = tcRateProblem
+		}
+	}
+	if cfg.checkEnd {
+		// start and end are the ones -start and -end print, so the check agrees with them.
+		start, tcFrames, err := newStart()
+		if err != nil {
+			return res, err
+		}
+		end, err := lastFrame(cfg.exclusiveEnd)
+		if err != nil {
+			return res, err
+		}
+		// duration counts frames of the video. When the timecode is of half its rate,
+		// or -ref-rate is given, it is fine to count them in the rate of the timecode.
+		n := frames
+		if tcRateProblem == "" || cfg.refRate != "" {
+			n = tcFrames
+		}
+		if !cfg.exclusiveEnd {
+			n--
+		}
+		res.endCheck = "ok"
+		// Frames doesn't wrap at midnight, so a mov crossing it is still ok.
+		if end.Frames()-start.Frames() != n {
+			want := *start
+			want.Add(n)
+			op := "- 1"
+			if cfg.exclusiveEnd {
+				op = "(-exclusive-end)"
+			}
+			res.endCheck = fmt.Sprintf("end %v isn't start %v + duration %v %v = %v", cfg.formatTimecode(end), cfg.formatTimecode(start), frames, op, cfg.formatTimecode(&want))
+			if tcRateProblem != "" {
+				res.endCheck += ", " + tcRateProblem
+			}
+		}
+	}
+	if cfg.checkDrop {
+		if timecode == "" {
+			return res, ErrMissingTimecode
+		}
+		var rate FrameRate
+		ok := false
+		if tmcd.rate > 0 {
+			rate, ok = lookupFPS(strconv.FormatFloat(tmcd.rate, 'f', -1, 64))
+		} else {
+			rate, ok = LookupFrameRate(videoRate)
+			if !ok {
+				rate, ok = lookupFPS(fps)
+			}
+		}
+		if !ok {
+			return res, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+		}
+		res.dropCheck = "ok"
+		if problem := checkDrop(timecode, rate); problem != "" {
+			res.dropCheck = problem
+		}
+	}
+	if cfg.trim != "" {
+		start, tcFrames, err := newStart()
+		if err != nil {
+			return res, err
+		}
+		rate, err := timecodeRate()
+		if err != nil {
+			return res, err
+		}
+		in, out, err := trimRange(cfg.trim, start, tcFrames)
+		if err != nil {
+			return res, err
 		}
-		if fps != "30" && fps != "29.97" && fps != "24" && fps != "23.98" && fps != "23.976" {
-			return res, fmt.Errorf("unsupported fps: %v", fps)
+		// out is inclusive, so the length includes the out frame.
+		res.trimStart = in.Seconds(rate) - start.Seconds(rate)
+		res.trimLength = float64(Diff(in, out)+1) / rate
+	}
+	if cfg.keyframeBefore != "" {
+		start, _, err := newStart()
+		if err != nil {
+			return res, err
 		}
-		base := 24
-		if fps == "30" || fps == "29.97" {
-			base = 30
+		rate, err := timecodeRate()
+		if err != nil {
+			return res, err
 		}
-		drop := false
-		if fps == "29.97" {
-			// contrary to our intuition 23.98 (or 23.976) isn't a drop frame system.
-			drop = true
+		if err := validateTimecode(cfg.keyframeBefore, start.Base(), start.Drop()); err != nil {
+			return res, fmt.Errorf("-keyframe-before: %w", err)
 		}
-		tc, err := NewTimecode(timecode, base, drop)
+		target, err := NewTimecode(cfg.keyframeBefore, start.Base(), start.Drop())
 		if err != nil {
 			return res, err
 		}
-		tc.Add(frames - 1)
-		res.end = tc.String()
+		if Diff(start, target) < 0 {
+			return res, fmt.Errorf("%w: %v is before the start %v", ErrTimecodeRange, cfg.keyframeBefore, start)
+		}
+		res.keyframe = keyframe{start: start, rate: rate, target: float64(Diff(start, target)) / rate}
+	}
+	if cfg.frameAt != "" {
+		start, tcFrames, err := newStart()
+		if err != nil {
+			return res, err
+		}
+		if tcFrames == 0 {
+			return res, noFrames()
+		}
+		rate, err := timecodeRate()
+		if err != nil {
+			return res, err
+		}
+		sec, err := parseWallClock(cfg.frameAt)
+		if err != nil {
+			return res, fmt.Errorf("-frame-at: %w", err)
+		}
+		n, tc, err := frameAt(start, tcFrames, sec, rate)
+		if err != nil {
+			return res, err
+		}
+		res.frameAt = strconv.Itoa(n) + "\t" + cfg.formatTimecode(tc)
+	}
+	if cfg.chapters {
+		chapters := parseChapters(data)
+		res.chapters = "none"
+		if len(chapters) != 0 {
+			start, _, err := newStart()
+			if errors.Is(err, ErrMissingTimecode) {
+				// chapters are times in the mov, so they are counted from zero without a timecode.
+				tag := timecode
+				timecode = "00:00:00:00"
+				start, _, err = newStart()
+				timecode = tag
+				if err == nil {
+					res.warnings = append(res.warnings, "the mov doesn't have a timecode, chapters are counted from "+cfg.formatTimecode(start))
+				}
+			}
+			if err != nil {
+				return res, err
+			}
+			rate, err := timecodeRate()
+			if err != nil {
+				return res, err
+			}
+			lines := make([]string, 0, len(chapters))
+			for _, c := range chapters {
+				// end of a chapter is the start of the next, so the last frame is before it.
+				s := *start
+				s.Add(cfg.roundFrames(c.start * rate))
+				e := *start
+				e.Add(cfg.roundFrames(c.end*rate) - 1)
+				lines = append(lines, c.title+"\t"+cfg.formatTimecode(&s)+"\t"+cfg.formatTimecode(&e))
+			}
+			res.chapters = strings.Join(lines, "\n")
+		}
 	}
 	if cfg.duration {
 		if frames == 0 {
-			return res, fmt.Errorf("missing nb_frames information")
+			return res, noFrames()
 		}
 		res.duration = strconv.Itoa(frames)
 	}
+	if cfg.humanDuration {
+		if frames == 0 {
+			return res, noFrames()
+		}
+		rate, err := parseRate(videoRate)
+		if err != nil {
+			rate, err = strconv.ParseFloat(fps, 64)
+			if err != nil {
+				return res, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+			}
+		}
+		res.humanDuration = formatHumanDuration(float64(frames) / rate)
+	}
+	if cfg.durationDiff {
+		res.durationDiff, err = durationDiff(parseStreamDurations(streams), cfg.durationThreshold)
+		if err != nil {
+			return res, err
+		}
+	}
 	if cfg.fps {
 		res.fps = fps
 	}
 	if cfg.resolution {
-		if width == "" {
-			return res, fmt.Errorf("missing width information")
-		}
-		if height == "" {
-			return res, fmt.Errorf("missing height information")
+		w, h, err := parseSize(width, height)
+		if err != nil {
+			return res, err
 		}
 		res.resolution = width + "*" + height
+		dw, dh := displaySize(w, h, sar, parseRotation(videoStream))
+		if dw != w || dh != h {
+			// rotated or anamorphic, the stored size isn't what viewers see.
+			res.resolution = fmt.Sprintf("coded %v*%v, display %v*%v", w, h, dw, dh)
+		}
+	}
+	if cfg.checkAspect {
+		w, h, err := parseSize(width, height)
+		if err != nil {
+			return res, err
+		}
+		res.aspectCheck = "ok"
+		if problem := checkAspect(w, h, sar); problem != "" {
+			res.aspectCheck = problem
+		}
+	}
+	if cfg.class {
+		w, h, err := parseSize(width, height)
+		if err != nil {
+			return res, err
+		}
+		res.class = classifyResolution(w, h)
+	}
+	if cfg.standard != "" {
+		w, h, err := parseSize(width, height)
+		if err != nil {
+			return res, err
+		}
+		// fps of the overview is preferred, as r_frame_rate could be the field rate.
+		rate, ok := lookupFPS(fps)
+		if !ok {
+			rate, ok = LookupFrameRate(videoRate)
+		}
+		if !ok {
+			return res, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+		}
+		res.standard = "ok"
+		if problem := checkStandard(cfg.standard, classifyResolution(w, h), fieldOrder, rate); problem != "" {
+			res.standard = problem
+		}
+	}
+	if cfg.limits.active() {
+		seconds := 0.0
+		if cfg.limits.duration() {
+			if frames == 0 {
+				return res, noFrames()
+			}
+			rate, err := parseRate(videoRate)
+			if err != nil {
+				rate, err = strconv.ParseFloat(fps, 64)
+				if err != nil {
+					return res, fmt.Errorf("%w: %v", ErrUnsupportedFPS, fps)
+				}
+			}
+			seconds = float64(frames) / rate
+		}
+		class := ""
+		if cfg.limits.resolution() {
+			w, h, err := parseSize(width, height)
+			if err != nil {
+				return res, err
+			}
+			class = classifyResolution(w, h)
+		}
+		res.outlier = strings.Join(cfg.limits.check(seconds, class), ", ")
 	}
 	if cfg.codec {
-		res.codec = strings.Title(strings.ToLower(codec)) + " " + codec_profile + " / " + pix_fmt
+		res.codecInfo = parseCodec(codec, codec_profile, level, pix_fmt)
+		res.codec = res.codecInfo.String()
 	}
 	if cfg.colorspace {
 		res.colorspace = colorspace
 	}
+	if cfg.colorInfo {
+		info, warnings := parseColorInfo(videoStream, videoLine)
+		res.colorInfo = info.String()
+		res.warnings = append(res.warnings, warnings...)
+	}
+	if cfg.pixfmt {
+		p, err := parsePixFmt(pix_fmt, colorRange)
+		if err != nil {
+			return res, err
+		}
+		res.pixfmt = p.String()
+	}
+	if cfg.stereo3D {
+		res.stereo3D = parseStereo3D(videoStream)
+	}
+	if cfg.projection {
+		res.projection = parseProjection(videoStream)
+	}
+	if cfg.cover {
+		res.cover = "none"
+		if pic, ok := findAttachedPic(streams); ok {
+			res.cover = pic
+		}
+	}
+	encoderSource := fmt.Sprintf("TAG:encoder of stream %v", videoIdx)
+	if cfg.encoder {
+		// writing application of the file is more helpful than the encoder of the stream.
+		for _, k := range []string{"com.apple.quicktime.software", "writing_application", "encoder"} {
+			if formatTags[k] != "" {
+				encoder = formatTags[k]
+				encoderSource = k + " of the format"
+				break
+			}
+		}
+		res.encoder = encoder
+	}
+	if cfg.brand {
+		res.brand = parseBrands(formatTags)
+	}
+	if cfg.creation {
+		// creation_time of the format is of the file, the stream's is a fallback.
+		if t := formatTags["creation_time"]; t != "" {
+			creationTime = t
+		}
+		res.creation = "none"
+		if creationTime != "" {
+			res.creation, err = formatCreationTime(creationTime, cfg.timezone, cfg.creationLayout)
+			if err != nil {
+				return res, err
+			}
+		}
+	}
+	reelSource := ""
+	if cfg.reel {
+		res.reel, reelSource = parseReel(streams, videoStream, formatTags)
+	}
+	if cfg.explain {
+		tcSource := fmt.Sprintf("TAG:timecode of stream %v", tcStream)
+		if fromFormat {
+			tcSource = "timecode of the format"
+		}
+		if strings.HasPrefix(cfg.startFrom, "+") {
+			tcSource += " + -start-from"
+		} else if cfg.startFrom != "" {
+			tcSource = "-start-from"
+		}
+		res.sources = map[string]string{
+			"start":          tcSource,
+			"end":            tcSource + " + " + framesSource,
+			"duration":       framesSource,
+			"human_duration": framesSource + " / " + fpsSource,
+			"fps":            fpsSource,
+			"resolution":     fmt.Sprintf("width, height of stream %v", videoIdx),
+			"class":          fmt.Sprintf("width, height of stream %v", videoIdx),
+			"encoder":        encoderSource,
+			"reel":           reelSource,
+		}
+	}
 	return res, nil
 }
+
+// tmcdInfo is information of a QuickTime timecode (tmcd) track.
+type tmcdInfo struct {
+	// index is the stream index of the track. It is -1 when there isn't a tmcd track.
+	index int
+	// rate is the frame rate of the track. It is 0 when unknown.
+	rate float64
+	// drop is whether the track uses drop frame timecode, which ffprobe
+	// indicates with a semicolon before the frame field.
+	drop bool
+	// tagged is whether the track has the timecode tag, that tells drop.
+	tagged bool
+}
+
+// findTmcd finds a tmcd track from streams and returns its information.
+// It returns tmcdInfo of index -1 when there isn't a tmcd track.
+func findTmcd(streams []string) tmcdInfo {
+	info := tmcdInfo{index: -1}
+	for i, stream := range streams {
+		isTmcd := false
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if l == "codec_tag_string=tmcd" {
+				isTmcd = true
+				break
+			}
+		}
+		if !isTmcd {
+			continue
+		}
+		info.index = i
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if strings.HasPrefix(l, "index=") {
+				if n, err := strconv.Atoi(strings.TrimPrefix(l, "index=")); err == nil {
+					info.index = n
+				}
+			}
+			if strings.HasPrefix(l, "avg_frame_rate=") {
+				rate, err := parseRate(strings.TrimPrefix(l, "avg_frame_rate="))
+				if err == nil {
+					info.rate = rate
+				}
+			}
+			if strings.HasPrefix(l, "TAG:timecode=") {
+				info.drop = strings.Contains(l, ";")
+				info.tagged = true
+			}
+		}
+		return info
+	}
+	return info
+}
+
+// parseTimecodes returns timecodes of all the streams that have one, as
+// stream index, timecode and rate separated by tab, one stream per line.
+// Rate is avg_frame_rate of the stream, or r_frame_rate when it isn't available.
+func parseTimecodes(streams []string) string {
+	lines := []string{}
+	for i, stream := range streams {
+		timecode := ""
+		avgRate := ""
+		rRate := ""
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if strings.HasPrefix(l, "TAG:timecode=") && timecode == "" {
+				timecode = strings.TrimPrefix(l, "TAG:timecode=")
+			}
+			if strings.HasPrefix(l, "avg_frame_rate=") {
+				avgRate = strings.TrimPrefix(l, "avg_frame_rate=")
+			}
+			if strings.HasPrefix(l, "r_frame_rate=") {
+				rRate = strings.TrimPrefix(l, "r_frame_rate=")
+			}
+		}
+		if timecode == "" {
+			continue
+		}
+		rate := "unknown"
+		for _, rt := range []string{avgRate, rRate} {
+			if fr, ok := LookupFrameRate(rt); ok {
+				rate = fr.String()
+				break
+			}
+			if f, err := parseRate(rt); err == nil && f > 0 {
+				rate = strconv.FormatFloat(f, 'f', -1, 64)
+				break
+			}
+		}
+		lines = append(lines, fmt.Sprintf("%v\t%v\t%v", i, timecode, rate))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineReader reads lines of s one by one.
+// Unlike strings.Split, it doesn't allocate for the lines.
+type lineReader struct {
+	s    string
+	line string
+}
+
+// next reads the next line to r.line, and reports whether there was one.
+func (r *lineReader) next() bool {
+	if r.s == "" {
+		return false
+	}
+	i := strings.IndexByte(r.s, '\n')
+	if i == -1 {
+		r.line, r.s = r.s, ""
+		return true
+	}
+	r.line, r.s = r.s[:i], r.s[i+1:]
+	return true
+}
+
+// parseOverviewDuration parses the Duration line of the overview,
+// that is in 1/100 seconds. (ex. Duration: 00:00:04.25, start: 0.000000, bitrate: 178198 kb/s)
+// It reports false when the overview doesn't have it, or it is N/A.
+func parseOverviewDuration(overview string) (float64, bool) {
+	for r := (lineReader{s: overview}); r.next(); {
+		l := strings.TrimSpace(r.line)
+		if !strings.HasPrefix(l, "Duration: ") {
+			continue
+		}
+		d, _, _ := strings.Cut(strings.TrimPrefix(l, "Duration: "), ",")
+		parts := strings.Split(d, ":")
+		if len(parts) != 3 {
+			return 0, false
+		}
+		h, herr := strconv.Atoi(parts[0])
+		m, merr := strconv.Atoi(parts[1])
+		sec, serr := strconv.ParseFloat(dotDecimal(parts[2]), 64)
+		if herr != nil || merr != nil || serr != nil {
+			return 0, false
+		}
+		return float64(h*3600+m*60) + sec, true
+	}
+	return 0, false
+}
+
+// parseRate parses a rational frame rate like 30000/1001 that ffprobe reports.
+func parseRate(rate string) (float64, error) {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid rate: %v", rate)
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate: %v", rate)
+	}
+	d, err := strconv.Atoi(den)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("invalid rate: %v", rate)
+	}
+	return float64(n) / float64(d), nil
+}
+
+// startOffset returns when the video starts in seconds, from start_time of the stream,
+// or start_pts in time_base when start_time isn't available. It returns 0 when neither is.
+func startOffset(startTime, startPts, timeBase string) float64 {
+	if t, err := strconv.ParseFloat(startTime, 64); err == nil {
+		return t
+	}
+	pts, err := strconv.Atoi(startPts)
+	if err != nil {
+		return 0
+	}
+	tb, err := parseRate(timeBase)
+	if err != nil {
+		return 0
+	}
+	return float64(pts) * tb
+}
+
+// formatHumanDuration formats seconds like 1h2m3.4s, rounded to 0.1 second.
+// Zero fields of hours and minutes are omitted, and so is zero fraction.
+func formatHumanDuration(sec float64) string {
+	tenths := int(math.Round(sec * 10))
+	h := tenths / 36000
+	m := tenths / 600 % 60
+	s := strconv.FormatFloat(float64(tenths%600)/10, 'f', -1, 64)
+	if h > 0 {
+		return fmt.Sprintf("%dh%dm%ss", h, m, s)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm%ss", m, s)
+	}
+	return s + "s"
+}
+
+// formatSeconds formats seconds in milliseconds precision.
+func formatSeconds(sec float64) string {
+	return strconv.FormatFloat(sec, 'f', 3, 64)
+}
+
+// samplePoints returns n evenly spaced sample points in a clip that starts from start
+// and has frames in the rate. Each line has the timecode in c's layout and seconds
+// from the start of the clip.
+func (c config) samplePoints(start *Timecode, frames, n int, rate float64) string {
+	lines := make([]string, 0, n)
+	startSec := start.Seconds(rate)
+	for i := 0; i < n; i++ {
+		offset := 0
+		if n > 1 {
+			offset = i * (frames - 1) / (n - 1)
+		}
+		tc := *start
+		tc.Add(offset)
+		sec := tc.Seconds(rate) - startSec
+		lines = append(lines, c.formatTimecode(&tc)+"\t"+formatSeconds(sec))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fieldsToFrames checks whether nb_frames of an interlaced video counts fields
+// instead of frames, by comparing it with its duration and rate.
+// It returns corrected number of frames and true when it does.
+func fieldsToFrames(frames int, fieldOrder, duration, rate string) (int, bool) {
+	switch fieldOrder {
+	case "tt", "bb", "tb", "bt":
+	default:
+		return frames, false
+	}
+	d, err := strconv.ParseFloat(duration, 64)
+	if err != nil {
+		return frames, false
+	}
+	r, err := parseRate(rate)
+	if err != nil {
+		return frames, false
+	}
+	expected := d * r
+	if math.Abs(float64(frames)-2*expected) > 1 {
+		return frames, false
+	}
+	return frames / 2, true
+}
+
+// maxFramesDiff is the difference between nb_frames and frames computed from duration,
+// that is tolerated as rounding of the duration.
+const maxFramesDiff = 1
+
+// computedFrames computes number of frames from duration and rate of the video,
+// and returns it with whether nb_frames matches it.
+// It returns true when it cannot compute, as there is nothing to compare.
+func computedFrames(frames int, duration, rate string, cfg config) (int, bool) {
+	d, err := strconv.ParseFloat(duration, 64)
+	if err != nil {
+		return frames, true
+	}
+	r, err := parseRate(rate)
+	if err != nil {
+		return frames, true
+	}
+	n := cfg.roundFrames(d * r)
+	diff := frames - n
+	if diff < 0 {
+		diff = -diff
+	}
+	return n, diff <= maxFramesDiff
+}
+
+// codecInfo is codec of the video in parts.
+type codecInfo struct {
+	// name is the codec name for reading. (ex. Prores, HEVC)
+	name    string
+	profile string
+	// level is the level of H.264 and HEVC. (ex. 5.1) It is empty for other codecs.
+	level  string
+	pixFmt string
+}
+
+// parseCodec makes codecInfo from the stream fields of ffprobe.
+// Note that ffprobe doesn't tell the tier of HEVC.
+func parseCodec(codec, profile, level, pixFmt string) codecInfo {
+	c := codecInfo{
+		name:    strings.Title(strings.ToLower(codec)),
+		profile: profile,
+		pixFmt:  pixFmt,
+	}
+	lv := 0
+	if n, err := strconv.Atoi(level); err == nil && n > 0 {
+		lv = n
+	}
+	switch codec {
+	case "hevc":
+		c.name = "HEVC"
+		if lv != 0 {
+			// ffprobe reports HEVC level multiplied by 30. (ex. 153 for 5.1)
+			c.level = strconv.FormatFloat(float64(lv)/30, 'f', 1, 64)
+		}
+	case "h264":
+		c.name = "H264"
+		if lv != 0 {
+			// ffprobe reports H.264 level multiplied by 10. (ex. 41 for 4.1)
+			c.level = strconv.FormatFloat(float64(lv)/10, 'f', 1, 64)
+		}
+	}
+	return c
+}
+
+// String formats the codecInfo for -codec. (ex. Prores HQ / yuv422p10le)
+// Level is added for H.264 and HEVC. (ex. HEVC Main 10 L5.1 / yuv420p10le)
+// Profile could have spaces or slashes itself, use the parts in json to split them.
+func (c codecInfo) String() string {
+	s := c.name + " " + c.profile
+	if c.level != "" {
+		s += " L" + c.level
+	}
+	return s + " / " + c.pixFmt
+}
+
+// parseFormatTags parses metadata of the input file from ffprobe overview.
+// They are the tags ffprobe prints as FORMAT tags, which -show_streams doesn't show.
+func parseFormatTags(overview string) map[string]string {
+	tags := map[string]string{}
+	inInput := false
+	inMeta := false
+	for r := (lineReader{s: overview}); r.next(); {
+		l := r.line
+		t := strings.TrimSpace(l)
+		if strings.HasPrefix(l, "Input #") {
+			inInput = true
+			continue
+		}
+		if !inInput {
+			continue
+		}
+		if strings.HasPrefix(t, "Duration:") || strings.HasPrefix(t, "Stream #") {
+			// metadata of the input ends here.
+			break
+		}
+		if t == "Metadata:" {
+			inMeta = true
+			continue
+		}
+		if !inMeta {
+			continue
+		}
+		k, v, ok := strings.Cut(t, ":")
+		if !ok {
+			continue
+		}
+		tags[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return tags
+}
+
+// parseBrands returns major_brand and compatible_brands of the format tags,
+// or none when there isn't a brand. (ex. qt (compatible qt))
+// compatible_brands are 4 characters each without a separator. (ex. isomiso2avc1mp41)
+func parseBrands(tags map[string]string) string {
+	major := tags["major_brand"]
+	if major == "" {
+		return "none"
+	}
+	compat := tags["compatible_brands"]
+	if compat == "" {
+		return major
+	}
+	// trailing spaces of a brand like "qt  " are trimmed from the tag.
+	for len(compat)%4 != 0 {
+		compat += " "
+	}
+	brands := []string{}
+	for i := 0; i < len(compat); i += 4 {
+		if b := strings.TrimSpace(compat[i : i+4]); b != "" {
+			brands = append(brands, b)
+		}
+	}
+	return fmt.Sprintf("%v (compatible %v)", major, strings.Join(brands, ", "))
+}
+
+// parseStereo3D parses stereo 3D side data of a stream, and returns its layout
+// as ffprobe names it. (ex. side by side, top and bottom)
+// It returns "2D" when the stream doesn't have it.
+func parseStereo3D(stream string) string {
+	for _, kv := range parseSideData(stream) {
+		if kv["side_data_type"] != "Stereo 3D" || kv["type"] == "" || kv["type"] == "2D" {
+			continue
+		}
+		if kv["inverted"] == "1" {
+			return kv["type"] + " (inverted)"
+		}
+		return kv["type"]
+	}
+	return "2D"
+}
+
+// parseProjection parses spherical mapping side data of a stream, that is of
+// spherical video (st3d and sv3d boxes). It returns the projection and how many degrees
+// it covers horizontally, which is 360 for 360 video. (ex. equirectangular (360))
+// The degrees are omitted for projections that aren't of a sphere, like fisheye.
+// It returns none when the stream doesn't have it.
+func parseProjection(stream string) string {
+	for _, kv := range parseSideData(stream) {
+		if kv["side_data_type"] != "Spherical Mapping" || kv["projection"] == "" {
+			continue
+		}
+		proj := kv["projection"]
+		switch proj {
+		case "equirectangular", "cubemap":
+			return proj + " (360)"
+		case "half equirectangular":
+			return proj + " (180)"
+		case "tiled equirectangular":
+			// bounds are fractions of the width cropped from each side, in 0.32 fixed point.
+			left, lerr := strconv.ParseFloat(kv["bound_left"], 64)
+			right, rerr := strconv.ParseFloat(kv["bound_right"], 64)
+			if lerr != nil || rerr != nil {
+				return proj
+			}
+			return fmt.Sprintf("%v (%v)", proj, math.Round(360*(1-(left+right)/(1<<32))))
+		}
+		return proj
+	}
+	return "none"
+}
+
+// parseSideData returns side data of a stream, as a map of its keys and values each.
+func parseSideData(stream string) []map[string]string {
+	sideData := []map[string]string{}
+	for _, sd := range strings.Split(stream, "[SIDE_DATA]")[1:] {
+		end := strings.Index(sd, "[/SIDE_DATA]")
+		if end == -1 {
+			break
+		}
+		kv := map[string]string{}
+		for r := (lineReader{s: sd[:end]}); r.next(); {
+			k, v, ok := strings.Cut(r.line, "=")
+			if ok {
+				kv[k] = v
+			}
+		}
+		sideData = append(sideData, kv)
+	}
+	return sideData
+}
+
+// findAttachedPic finds a stream of attached picture, which is usually a cover art,
+// and returns its codec and resolution. (ex. mjpeg 600*600)
+func findAttachedPic(streams []string) (string, bool) {
+	for _, stream := range streams {
+		isPic := false
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if l == "DISPOSITION:attached_pic=1" {
+				isPic = true
+				break
+			}
+		}
+		if !isPic {
+			continue
+		}
+		codec := ""
+		width := ""
+		height := ""
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if strings.HasPrefix(l, "codec_name=") {
+				codec = strings.TrimPrefix(l, "codec_name=")
+			}
+			if strings.HasPrefix(l, "width=") {
+				width = strings.TrimPrefix(l, "width=")
+			}
+			if strings.HasPrefix(l, "height=") {
+				height = strings.TrimPrefix(l, "height=")
+			}
+		}
+		return codec + " " + width + "*" + height, true
+	}
+	return "", false
+}
+
+// dotDecimal replaces decimal comma of a number with dot, as ffprobe might print
+// numbers in the locale. (ex. 23,98 to 23.98)
+func dotDecimal(n string) string {
+	return strings.Replace(n, ",", ".", 1)
+}
+
+// checkTruncated checks ffprobe output isn't cut in the middle, as when ffprobe
+// is killed for a timeout. Every [STREAM] should be closed, and there should be
+// as many of them as the streams in the overview, when it is given.
+// Partial output could otherwise give wrong values without an error.
+func checkTruncated(overview, streamData string) error {
+	open := strings.Count(streamData, "[STREAM]")
+	closed := strings.Count(streamData, "[/STREAM]")
+	if open != closed {
+		return fmt.Errorf("%w: %v of %v streams are closed", ErrTruncated, closed, open)
+	}
+	if n := strings.Count(overview, "Stream #0:"); overview != "" && closed < n {
+		return fmt.Errorf("%w: %v of %v streams", ErrTruncated, closed, n)
+	}
+	return nil
+}
+
+// parseStartOnly parses the start timecode from ffprobe output of startOnlyArgs.
+// It gets the same start as parse, from the video stream or the other streams,
+// without parsing the overview that isn't in the output.
+func parseStartOnly(data string, cfg config) (string, error) {
+	sects := strings.Split(data, "[STREAM]")
+	if len(sects) == 1 {
+		return "", noStreamError(data)
+	}
+	if err := checkTruncated("", data); err != nil {
+		return "", err
+	}
+	video := ""
+	other := ""
+	hasVideo := false
+	for _, sect := range sects[1:] {
+		isVideo := false
+		timecode := ""
+		for r := (lineReader{s: sect}); r.next(); {
+			l := r.line
+			if l == "codec_type=video" {
+				isVideo = true
+			}
+			if l == "DISPOSITION:attached_pic=1" {
+				// cover image isn't the video.
+				isVideo = false
+				break
+			}
+			if strings.HasPrefix(l, "TAG:timecode=") {
+				timecode = cleanTimecodeTag(strings.TrimPrefix(l, "TAG:timecode="), cfg.strictTimecodeLength)
+			}
+		}
+		if isVideo && !hasVideo {
+			hasVideo = true
+			video = timecode
+		} else if other == "" {
+			other = timecode
+		}
+	}
+	if !hasVideo {
+		return "", ErrNoVideoStream
+	}
+	timecode := ""
+	for _, src := range cfg.timecodeSources() {
+		if src == tcVideo {
+			timecode = video
+		} else if src == tcStreams {
+			timecode = other
+		} else {
+			// the format isn't in the output, the full probe reads it.
+			break
+		}
+		if timecode != "" {
+			break
+		}
+	}
+	if timecode == "" {
+		return "", ErrMissingTimecode
+	}
+	if len(strings.TrimPrefix(timecode, "-")) != 11 {
+		return "", fmt.Errorf("%w: %v", ErrInvalidTimecode, timecode)
+	}
+	return timecode, nil
+}
+
+// noStreamLines is how many lines of ffprobe output are shown when it doesn't have [STREAM].
+const noStreamLines = 5
+
+// noStreamError returns the error of ffprobe output that doesn't have [STREAM] lines.
+// Empty output is of ffprobe that succeeded without reading anything from the file,
+// which is usually a corrupt file rather than wrong arguments of ffprobe.
+func noStreamError(data string) error {
+	if strings.TrimSpace(data) == "" {
+		return ErrEmptyOutput
+	}
+	return fmt.Errorf("%w: %v", ErrNoStream, noStreamHint(data))
+}
+
+// noStreamHint explains ffprobe output that doesn't have [STREAM] lines,
+// with the first lines of it.
+func noStreamHint(data string) string {
+	hint := "ffprobe should print streams in its default format with -show_streams, see the whole output with -raw"
+	trimmed := strings.TrimSpace(data)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "<") {
+		hint = "ffprobe printed JSON or XML, movinfo only reads its default format. check -of or -print_format isn't set for ffprobe"
+	}
+	lines := []string{}
+	for _, l := range strings.Split(trimmed, "\n") {
+		if len(lines) == noStreamLines {
+			lines = append(lines, "\t...")
+			break
+		}
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, "\t"+strings.TrimSpace(l))
+		}
+	}
+	return hint + ", the output starts with:\n" + strings.Join(lines, "\n")
+}
+
+// trimRange parses in and out timecode of -trim, in the timecode system of start.
+// They should be in the clip that starts from start and has frames.
+func trimRange(trim string, start *Timecode, frames int) (in, out *Timecode, err error) {
+	inCode, outCode, ok := strings.Cut(trim, ",")
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: need in and out separated by comma: %v", ErrInvalidTimecode, trim)
+	}
+	codes := []string{strings.TrimSpace(inCode), strings.TrimSpace(outCode)}
+	for _, code := range codes {
+		if err := validateTimecode(code, start.Base(), start.Drop()); err != nil {
+			return nil, nil, err
+		}
+	}
+	in, err = NewTimecode(codes[0], start.Base(), start.Drop())
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err = NewTimecode(codes[1], start.Base(), start.Drop())
+	if err != nil {
+		return nil, nil, err
+	}
+	if Diff(start, in) < 0 || Diff(in, out) < 0 || (frames != 0 && Diff(start, out) >= frames) {
+		return nil, nil, fmt.Errorf("%w: %v is not in the mov", ErrTimecodeRange, trim)
+	}
+	return in, out, nil
+}
+
+// trimCommand returns an ffmpeg command that extracts length seconds from start seconds of the file.
+// The output is named after the file. (ex. a.mov -> a_trim.mov)
+func trimCommand(file string, start, length float64) string {
+	ext := filepath.Ext(file)
+	outFile := strings.TrimSuffix(filepath.Base(file), ext) + "_trim" + ext
+	return "ffmpeg -ss " + formatSeconds(start) + " -i " + shellQuote(file) + " -t " + formatSeconds(length) + " " + shellQuote(outFile)
+}
+
+// shellQuote quotes s in single quotes for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// chapter is a chapter of a mov. start and end are in seconds.
+type chapter struct {
+	title string
+	start float64
+	end   float64
+}
+
+// parseChapters parses chapters that ffprobe shows with -show_chapters.
+func parseChapters(data string) []chapter {
+	chapters := []chapter{}
+	for _, sect := range strings.Split(data, "[CHAPTER]")[1:] {
+		end := strings.Index(sect, "[/CHAPTER]")
+		if end == -1 {
+			break
+		}
+		c := chapter{}
+		for r := (lineReader{s: sect[:end]}); r.next(); {
+			l := r.line
+			if strings.HasPrefix(l, "start_time=") {
+				c.start, _ = strconv.ParseFloat(dotDecimal(strings.TrimPrefix(l, "start_time=")), 64)
+			}
+			if strings.HasPrefix(l, "end_time=") {
+				c.end, _ = strconv.ParseFloat(dotDecimal(strings.TrimPrefix(l, "end_time=")), 64)
+			}
+			if strings.HasPrefix(l, "TAG:title=") {
+				c.title = strings.TrimPrefix(l, "TAG:title=")
+			}
+		}
+		chapters = append(chapters, c)
+	}
+	return chapters
+}
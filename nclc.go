@@ -0,0 +1,137 @@
+package movinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Names of color primaries, transfer characteristics and matrix coefficients
+// by their codes in ITU-T H.273, that QuickTime NCLC atom and ffprobe use.
+// The names are as ffprobe prints them.
+var (
+	colorPrimaries = map[int]string{
+		1: "bt709", 2: "unknown", 4: "bt470m", 5: "bt470bg", 6: "smpte170m", 7: "smpte240m",
+		8: "film", 9: "bt2020", 10: "smpte428", 11: "smpte431", 12: "smpte432", 22: "ebu3213",
+	}
+	colorTransfers = map[int]string{
+		1: "bt709", 2: "unknown", 4: "bt470m", 5: "bt470bg", 6: "smpte170m", 7: "smpte240m",
+		8: "linear", 9: "log100", 10: "log316", 11: "iec61966-2-4", 12: "bt1361e", 13: "iec61966-2-1",
+		14: "bt2020-10", 15: "bt2020-12", 16: "smpte2084", 17: "smpte428", 18: "arib-std-b67",
+	}
+	colorMatrices = map[int]string{
+		0: "gbr", 1: "bt709", 2: "unknown", 4: "fcc", 5: "bt470bg", 6: "smpte170m", 7: "smpte240m",
+		8: "ycgco", 9: "bt2020nc", 10: "bt2020c", 11: "smpte2085", 12: "chroma-derived-nc",
+		13: "chroma-derived-c", 14: "ictcp",
+	}
+)
+
+// colorInfo is color description of the video.
+type colorInfo struct {
+	primaries string
+	transfer  string
+	matrix    string
+}
+
+func (c colorInfo) String() string {
+	return fmt.Sprintf("primaries %v, transfer %v, matrix %v", c.primaries, c.transfer, c.matrix)
+}
+
+// colorName returns the name of a color value of ffprobe, that could be a name
+// or a numeric code of names. Unknown codes are kept with the code. (ex. unknown (3))
+func colorName(v string, names map[int]string) string {
+	if v == "" {
+		return "unknown"
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return v
+	}
+	if name, ok := names[n]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%v)", n)
+}
+
+// parseColorInfo parses color_primaries, color_transfer and color_space of the video stream,
+// reading numeric codes as their names. Values of the stream are reconciled with the ones
+// in the stream line of the overview (ex. yuv422p10le(tv, bt709/bt709/unknown, progressive)),
+// which are used when the stream doesn't know them, and warned about when they differ.
+func parseColorInfo(stream, streamLine string) (colorInfo, []string) {
+	primaries, transfer, matrix := "", "", ""
+	for r := (lineReader{s: stream}); r.next(); {
+		l := r.line
+		if strings.HasPrefix(l, "color_primaries=") && primaries == "" {
+			primaries = strings.TrimPrefix(l, "color_primaries=")
+		}
+		if strings.HasPrefix(l, "color_transfer=") && transfer == "" {
+			transfer = strings.TrimPrefix(l, "color_transfer=")
+		}
+		if strings.HasPrefix(l, "color_space=") && matrix == "" {
+			matrix = strings.TrimPrefix(l, "color_space=")
+		}
+	}
+	info := colorInfo{
+		primaries: colorName(primaries, colorPrimaries),
+		transfer:  colorName(transfer, colorTransfers),
+		matrix:    colorName(matrix, colorMatrices),
+	}
+	warnings := []string{}
+	over, ok := overviewColors(streamLine)
+	if !ok {
+		return info, warnings
+	}
+	for _, c := range []struct {
+		name  string
+		value *string
+		over  string
+	}{
+		{"primaries", &info.primaries, over.primaries},
+		{"transfer", &info.transfer, over.transfer},
+		{"matrix", &info.matrix, over.matrix},
+	} {
+		if c.over == "unknown" || c.over == *c.value {
+			continue
+		}
+		if strings.HasPrefix(*c.value, "unknown") {
+			*c.value = c.over
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("color %v of the stream is %v, but the overview has %v", c.name, *c.value, c.over))
+	}
+	return info, warnings
+}
+
+// overviewColors finds colors in the stream line of the overview. ffmpeg prints them
+// in parentheses after pix_fmt as matrix/primaries/transfer, or only one name when
+// they are the same. (ex. yuv422p10le(tv, bt709/bt709/unknown, progressive))
+// It reports false when the line doesn't have them.
+func overviewColors(streamLine string) (colorInfo, bool) {
+	for rest := streamLine; ; {
+		var group string
+		var ok bool
+		_, rest, ok = strings.Cut(rest, "(")
+		if !ok {
+			return colorInfo{}, false
+		}
+		group, rest, _ = strings.Cut(rest, ")")
+		for _, part := range strings.Split(group, ", ") {
+			if names := strings.Split(part, "/"); len(names) == 3 {
+				return colorInfo{primaries: names[1], transfer: names[2], matrix: names[0]}, true
+			}
+			if isColorName(part, colorPrimaries) && isColorName(part, colorTransfers) && isColorName(part, colorMatrices) {
+				return colorInfo{primaries: part, transfer: part, matrix: part}, true
+			}
+		}
+	}
+}
+
+// isColorName reports whether the name is one of the names.
+func isColorName(name string, names map[int]string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
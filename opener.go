@@ -0,0 +1,72 @@
+package movinfo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Opener makes a file reachable for ffprobe, like extracting it from a tar or
+// staging it from tape. It returns the path for ffprobe, which could also be a url
+// ffprobe reads, and a function to clean it up after the probe, or nil.
+type Opener func(file string) (path string, cleanup func(), err error)
+
+// ProbeAllWith is like ProbeAll, but opens the file with open first.
+// The result still has the file, not the path open returned.
+func ProbeAllWith(file string, open Opener) (*Info, error) {
+	ctx, cancel := config{}.probeContext()
+	defer cancel()
+	return probeAllWith(ctx, file, config{}, open)
+}
+
+// openFile opens the file with open, and returns the path to probe and its cleanup.
+// The file is probed as is when open is nil. cleanup is never nil.
+func openFile(file string, open Opener) (string, func(), error) {
+	if open == nil {
+		return file, func() {}, nil
+	}
+	path, cleanup, err := open(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("couldn't open %v: %w", file, err)
+	}
+	if cleanup == nil {
+		cleanup = func() {}
+	}
+	return path, cleanup, nil
+}
+
+// opener returns the Opener of -pre-exec, or nil when it isn't set.
+// The command is killed when ctx is done, like ffprobe of -timeout.
+func (c config) opener(ctx context.Context) Opener {
+	if c.preExec == "" {
+		return nil
+	}
+	return execOpener(ctx, c.preExec)
+}
+
+// execOpener returns an Opener that runs the command with the file as the last argument.
+// The command is split at spaces without a shell, so an argument can't have spaces
+// even in quotes. A script of its own could wrap a command that needs them.
+// The command prints the path to probe, or nothing to probe the file itself.
+// What the command prepared is left to it, as movinfo doesn't know how to clean it up.
+func execOpener(ctx context.Context, command string) Opener {
+	return func(file string) (string, func(), error) {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return "", nil, fmt.Errorf("empty -pre-exec command")
+		}
+		c := exec.CommandContext(ctx, args[0], append(args[1:], file)...)
+		c.Stderr = os.Stderr
+		out, err := c.Output()
+		if err != nil {
+			return "", nil, fmt.Errorf("-pre-exec %v: %w", command, err)
+		}
+		path := strings.TrimSpace(string(out))
+		if path == "" {
+			path = file
+		}
+		return path, nil, nil
+	}
+}
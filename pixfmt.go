@@ -0,0 +1,103 @@
+package movinfo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// pixFormat is a pixel format broken down into its components.
+type pixFormat struct {
+	// subsampling is chroma subsampling. (ex. 4:2:2)
+	// Gray formats are 4:0:0, and RGB formats are 4:4:4.
+	subsampling string
+	depth       int
+	// full is true for full range, false for limited range.
+	full bool
+	// endian is "little-endian", "big-endian", or "" for 8-bit formats.
+	endian string
+}
+
+// String formats the pixFormat for -pixfmt. (ex. 4:2:2 10-bit limited little-endian)
+func (p pixFormat) String() string {
+	rng := "limited"
+	if p.full {
+		rng = "full"
+	}
+	s := fmt.Sprintf("%v %v-bit %v", p.subsampling, p.depth, rng)
+	if p.endian != "" {
+		s += " " + p.endian
+	}
+	return s
+}
+
+// yuvPixFmt matches planar yuv formats of ffmpeg. (ex. yuv422p10le, yuvj420p, yuva444p12be)
+var yuvPixFmt = regexp.MustCompile(`^(yuvj|yuva|yuv)(4[0-4][0-4])p(\d+)?(le|be)?$`)
+
+// rgbPixFmt matches planar rgb and gray formats of ffmpeg. (ex. gbrp10le, gray16le)
+var rgbPixFmt = regexp.MustCompile(`^(gbrap|gbrp|gray)(\d+)?(le|be)?$`)
+
+// packedPixFmts are pixel formats that don't follow the planar naming.
+var packedPixFmts = map[string]pixFormat{
+	"nv12":     {"4:2:0", 8, false, ""},
+	"nv21":     {"4:2:0", 8, false, ""},
+	"nv16":     {"4:2:2", 8, false, ""},
+	"p010le":   {"4:2:0", 10, false, "little-endian"},
+	"p016le":   {"4:2:0", 16, false, "little-endian"},
+	"p210le":   {"4:2:2", 10, false, "little-endian"},
+	"y210le":   {"4:2:2", 10, false, "little-endian"},
+	"uyvy422":  {"4:2:2", 8, false, ""},
+	"yuyv422":  {"4:2:2", 8, false, ""},
+	"rgb24":    {"4:4:4", 8, true, ""},
+	"bgr24":    {"4:4:4", 8, true, ""},
+	"rgba":     {"4:4:4", 8, true, ""},
+	"bgra":     {"4:4:4", 8, true, ""},
+	"argb":     {"4:4:4", 8, true, ""},
+	"abgr":     {"4:4:4", 8, true, ""},
+	"rgb48le":  {"4:4:4", 16, true, "little-endian"},
+	"rgb48be":  {"4:4:4", 16, true, "big-endian"},
+	"rgba64le": {"4:4:4", 16, true, "little-endian"},
+	"rgba64be": {"4:4:4", 16, true, "big-endian"},
+}
+
+// parsePixFmt breaks down ffmpeg pixel format name into its components.
+// colorRange is color_range of the stream. It decides the range when it is
+// tv or pc, otherwise yuvj and rgb formats are full range and the others are limited.
+func parsePixFmt(name, colorRange string) (pixFormat, error) {
+	p, ok := packedPixFmts[name]
+	if !ok {
+		var depth, endian string
+		if m := yuvPixFmt.FindStringSubmatch(name); m != nil {
+			p.subsampling = m[2][:1] + ":" + m[2][1:2] + ":" + m[2][2:]
+			p.full = m[1] == "yuvj"
+			depth, endian = m[3], m[4]
+		} else if m := rgbPixFmt.FindStringSubmatch(name); m != nil {
+			p.subsampling = "4:4:4"
+			p.full = true
+			if m[1] == "gray" {
+				p.subsampling = "4:0:0"
+				p.full = false
+			}
+			depth, endian = m[2], m[3]
+		} else {
+			return pixFormat{}, fmt.Errorf("%w: %v", ErrUnknownPixFmt, name)
+		}
+		p.depth = 8
+		if depth != "" {
+			p.depth, _ = strconv.Atoi(depth)
+		}
+		switch endian {
+		case "le":
+			p.endian = "little-endian"
+		case "be":
+			p.endian = "big-endian"
+		}
+	}
+	switch colorRange {
+	case "tv":
+		p.full = false
+	case "pc":
+		p.full = true
+	}
+	return p, nil
+}
@@ -0,0 +1,557 @@
+package movinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// probe runs ffprobe for the file with the args and returns its output.
+// The output includes the overview that ffprobe prints to stderr,
+// as parse needs both of them.
+func probe(ctx context.Context, cmd []string, file string, args ...string) (string, error) {
+	b, err := ffprobeCommand(ctx, cmd, file, args).CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("%w: %v", ErrProbe, ctx.Err())
+		}
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			// ffprobe didn't run at all.
+			return "", fmt.Errorf("%w: %v", errProbeOpen, err)
+		}
+		if openFailed(string(b)) {
+			return "", fmt.Errorf("%w: %s", errProbeOpen, b)
+		}
+		return "", fmt.Errorf("%w: %s", ErrProbe, b)
+	}
+	return string(b), nil
+}
+
+// errProbeOpen is ErrProbe of ffprobe that failed to run, or to open the file.
+// They could pass on a retry, unlike a corrupt file.
+var errProbeOpen = fmt.Errorf("%w", ErrProbe)
+
+// openErrors are errors of the system that ffprobe prints after the file name,
+// when it couldn't open the file. Networked storage has them for a moment.
+var openErrors = []string{
+	"No such file or directory",
+	"Input/output error",
+	"Resource temporarily unavailable",
+	"Connection timed out",
+	"Operation timed out",
+	"Connection reset by peer",
+	"Stale file handle",
+	"Stale NFS file handle",
+	"Network is unreachable",
+	"Host is down",
+}
+
+// openFailed reports whether ffprobe output says it couldn't open the file.
+// (ex. a.mov: Input/output error)
+func openFailed(out string) bool {
+	for r := (lineReader{s: out}); r.next(); {
+		for _, e := range openErrors {
+			if strings.HasSuffix(strings.TrimSpace(r.line), ": "+e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ffprobeCommand returns the command that runs ffprobe cmd for the file with the args.
+// cmd is the ffprobe binary followed by extra arguments from -ffprobe-arg.
+func ffprobeCommand(ctx context.Context, cmd []string, file string, args []string) *exec.Cmd {
+	c := exec.CommandContext(ctx, cmd[0], ffprobeArgv(cmd, file, args)[1:]...)
+	// numbers in C locale always use decimal dot.
+	c.Env = append(os.Environ(), "LC_ALL=C")
+	return c
+}
+
+// ffprobeArgv returns the argv for ffprobe cmd, the file and the args.
+// Extra arguments in cmd come first, so the arguments movinfo needs
+// for parsing, like -show_streams, override them.
+func ffprobeArgv(cmd []string, file string, args []string) []string {
+	argv := append([]string{}, cmd...)
+	argv = append(argv, args...)
+	return append(argv, file)
+}
+
+// probeStream runs ffprobe for the file with the args, and passes its stdout to read
+// while it runs, so that a long output doesn't need to be in the memory.
+func probeStream(ctx context.Context, cmd []string, file string, args []string, read func(r io.Reader) error) error {
+	c := ffprobeCommand(ctx, cmd, file, args)
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr := &strings.Builder{}
+	c.Stderr = stderr
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("%w: %v", ErrProbe, err)
+	}
+	rerr := read(stdout)
+	// ffprobe blocks on writing, if read stopped early.
+	io.Copy(io.Discard, stdout)
+	if err := c.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: %v", ErrProbe, ctx.Err())
+		}
+		return fmt.Errorf("%w: %s", ErrProbe, stderr.String())
+	}
+	return rerr
+}
+
+// retryBackoff is the wait before the first retry of ffprobe. It doubles every retry.
+const retryBackoff = 200 * time.Millisecond
+
+// probeRetry runs probe, and retries it up to retries times when ffprobe fails to run
+// or to open the file. Other failures wouldn't change, so they aren't retried.
+// It gives up when ctx is done while waiting.
+func probeRetry(ctx context.Context, retries int, cmd []string, file string, args ...string) (string, error) {
+	wait := retryBackoff
+	for i := 0; ; i++ {
+		out, err := probe(ctx, cmd, file, args...)
+		if !errors.Is(err, errProbeOpen) || i >= retries || ctx.Err() != nil {
+			return out, err
+		}
+		select {
+		case <-ctx.Done():
+			return "", err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// probeFile probes the file and parses the output for cfg.
+func probeFile(file string, cfg config) (result, error) {
+	ctx, cancel := cfg.probeContext()
+	defer cancel()
+	file, cleanup, err := openFile(file, cfg.opener(ctx))
+	if err != nil {
+		return result{}, err
+	}
+	defer cleanup()
+	cmd := cfg.ffprobeCmd()
+	args := baseArgs(cfg)
+	seq := isImageSequence(file)
+	if seq {
+		seqArgs, err := sequenceArgs(file, cfg.framerate)
+		if err != nil {
+			return result{}, err
+		}
+		args = append(seqArgs, args...)
+	}
+	// only the first probe of the mov is retried, so the fallbacks after it
+	// don't retry again. They run once each.
+	retries := cfg.retries
+	if startOnly(cfg) && !seq {
+		out, err := probeRetry(ctx, retries, cmd, file, startOnlyArgs...)
+		retries = 0
+		if err == nil {
+			if start, err := parseStartOnly(out, cfg); err == nil {
+				if cfg.raw {
+					dumpRaw(out)
+				}
+				return result{start: start}, nil
+			}
+		}
+		// the full probe tells better about the problem.
+	}
+	// only ask for the fields we need, it's much smaller than -show_streams.
+	out, err := probeRetry(ctx, retries, cmd, file, append(args, "-show_entries", showEntries(cfg))...)
+	if err == nil {
+		if res, err := parse(out, cfg); err == nil {
+			if cfg.raw {
+				dumpRaw(out)
+			}
+			return res, probeExtra(ctx, cmd, file, cfg, &res)
+		}
+	}
+	// fallback to the full stream information.
+	out, err = probe(ctx, cmd, file, append(args, "-show_streams")...)
+	if err != nil {
+		return result{}, err
+	}
+	if cfg.raw {
+		dumpRaw(out)
+	}
+	res, err := parse(out, cfg)
+	if (errors.Is(err, ErrMissingTimecode) || errors.Is(err, ErrInvalidTimecode)) && cfg.startFrom == "" && !seq && cfg.hasTimecodeSource(tcTmcdFrame) {
+		// the tmcd track has the start as a frame number, even when the tag doesn't.
+		if start, terr := probeTmcdStart(ctx, cmd, file, cfg, out); terr == nil {
+			c := cfg
+			c.startFrom = start
+			res, err = parse(out, c)
+			if err == nil {
+				res.warnings = append(res.warnings, "timecode tag is missing or invalid, using frame number of the tmcd track: "+start)
+				if cfg.timecodeSource {
+					res.timecodeSource = tcTmcdFrame
+				}
+			}
+		}
+	}
+	if err != nil {
+		return res, err
+	}
+	return res, probeExtra(ctx, cmd, file, cfg, &res)
+}
+
+// baseArgs returns ffprobe arguments for cfg, that are needed both for
+// -show_entries and -show_streams.
+func baseArgs(cfg config) []string {
+	args := []string{}
+	if cfg.countFrames {
+		args = append(args, "-count_frames")
+	}
+	if cfg.chapters {
+		args = append(args, "-show_chapters")
+	}
+	return args
+}
+
+// dryRun returns ffprobe commands that movinfo runs for the file, one command per line.
+// The fallback to -show_streams, which only runs when the first command fails, isn't included.
+// With -pre-exec, its command comes first, and the others have the file as the path it prints
+// isn't known yet.
+func dryRun(file string, cfg config, all bool) []string {
+	cmds := [][]string{}
+	if all {
+		cmds = append(cmds, allArgs(cfg), hdrArgs)
+	} else if startOnly(cfg) && !isImageSequence(file) {
+		cmds = append(cmds, startOnlyArgs)
+	} else {
+		args := baseArgs(cfg)
+		if isImageSequence(file) {
+			// the start number depends on the files, which aren't looked into.
+			args = append([]string{"-f", "image2", "-framerate", cfg.framerate, "-start_number", "..."}, args...)
+		}
+		cmds = append(cmds, append(args, "-show_entries", showEntries(cfg)))
+		if cfg.hdr {
+			cmds = append(cmds, hdrArgs)
+		}
+		if cfg.bitrate {
+			args := append([]string{}, bitrateArgs...)
+			if cfg.bitrateEvery > 1 {
+				// the intervals depend on duration of the mov, which isn't known yet.
+				args = append(args, "-read_intervals", "...")
+			}
+			cmds = append(cmds, args)
+		}
+		if cfg.gop {
+			cmds = append(cmds, gopArgs)
+		}
+		if cfg.gopStructure {
+			cmds = append(cmds, gopStructureArgs)
+		}
+		if cfg.scanType || cfg.detelecineEnd {
+			cmds = append(cmds, scanArgs)
+		}
+		if cfg.keyframeBefore != "" {
+			cmds = append(cmds, keyframeArgs)
+		}
+		if cfg.contentHash > 0 {
+			cmds = append(cmds, contentHashArgs(cfg.contentHash))
+		}
+		if cfg.fragmented {
+			cmds = append(cmds, fragmentArgs)
+		}
+	}
+	argvs := make([][]string, 0, len(cmds)+1)
+	for _, args := range cmds {
+		argvs = append(argvs, ffprobeArgv(cfg.ffprobeCmd(), file, args))
+	}
+	if cfg.loudness && !all {
+		ffmpeg := cfg.ffmpeg
+		if ffmpeg == "" {
+			ffmpeg = "ffmpeg"
+		}
+		argvs = append(argvs, append([]string{ffmpeg}, loudnessArgs(file)...))
+	}
+	lines := make([]string, 0, len(argvs)+1)
+	if args := strings.Fields(cfg.preExec); len(args) != 0 {
+		lines = append(lines, quoteArgv(append(args, file)))
+	}
+	for _, argv := range argvs {
+		// probe runs ffprobe and ffmpeg in C locale.
+		lines = append(lines, "LC_ALL=C "+quoteArgv(argv))
+	}
+	return lines
+}
+
+// quoteArgv quotes the arguments with quoteArg, and joins them with spaces.
+func quoteArgv(argv []string) string {
+	quoted := make([]string, 0, len(argv))
+	for _, a := range argv {
+		quoted = append(quoted, quoteArg(a))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteArg quotes a for POSIX shells, only when it has characters other than
+// letters, digits and a few safe punctuations.
+func quoteArg(a string) string {
+	if a == "" {
+		return "''"
+	}
+	for _, r := range a {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=,+@", r)) {
+			return shellQuote(a)
+		}
+	}
+	return a
+}
+
+// startOnlyArgs are ffprobe arguments for the start timecode, without the overview.
+var startOnlyArgs = []string{"-v", "error", "-show_entries", "stream=codec_type:stream_tags=timecode:stream_disposition=attached_pic"}
+
+// startOnly reports whether cfg only asks for the start timecode as it is in the mov,
+// which doesn't need the overview and other fields of streams.
+func startOnly(cfg config) bool {
+	if !cfg.start || cfg.computedStart() || cfg.forceDrop || cfg.strict || cfg.countFrames || cfg.explain {
+		return false
+	}
+	for _, f := range fieldFlags {
+		if f.name != "start" && f.set(cfg) {
+			return false
+		}
+	}
+	return true
+}
+
+// ffprobeCmd returns the ffprobe binary to run, followed by extra arguments for it.
+func (c config) ffprobeCmd() []string {
+	bin := c.ffprobe
+	if bin == "" {
+		bin = "ffprobe"
+	}
+	cmd := []string{bin}
+	return append(cmd, c.ffprobeArgs...)
+}
+
+// probeContext returns a context for probing a mov, which is done after the timeout.
+func (c config) probeContext() (context.Context, context.CancelFunc) {
+	if c.timeout > 0 {
+		return context.WithTimeout(context.Background(), c.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// probeExtra probes the file again for the fields -show_streams doesn't have.
+func probeExtra(ctx context.Context, cmd []string, file string, cfg config, res *result) error {
+	if cfg.hdr {
+		out, err := probe(ctx, cmd, file, hdrArgs...)
+		if err != nil {
+			return err
+		}
+		if cfg.raw {
+			dumpRaw(out)
+		}
+		res.hdr, err = parseHDR(out)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.fragmented {
+		var err error
+		res.fragmented, err = probeFragmented(ctx, cmd, file, cfg.raw)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.trim != "" {
+		res.trim = trimCommand(file, res.trimStart, res.trimLength)
+	}
+	if cfg.gop {
+		var err error
+		res.gop, err = probeGOP(ctx, cmd, file)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.gopStructure {
+		var err error
+		res.gopStructure, err = probeGOPStructure(ctx, cmd, file)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.keyframeBefore != "" {
+		var err error
+		res.keyframeBefore, err = probeKeyframeBefore(ctx, cmd, file, cfg, res.keyframe)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.contentHash > 0 {
+		var err error
+		res.contentHash, err = probeContentHash(ctx, cmd, file, cfg.contentHash)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.scanType {
+		var err error
+		res.scanType, err = probeScanType(ctx, cmd, file)
+		if err != nil {
+			return err
+		}
+	}
+	if cfg.detelecineEnd {
+		scanType := res.scanType
+		if !cfg.scanType && res.telecine.filmEnd != "" {
+			var err error
+			scanType, err = probeScanType(ctx, cmd, file)
+			if err != nil {
+				return err
+			}
+		}
+		res.detelecineEnd = res.telecine.format(scanType)
+	}
+	if cfg.loudness {
+		var problem string
+		res.loudness, problem = probeLoudness(ctx, cfg.ffmpeg, file)
+		if problem != "" {
+			res.warnings = append(res.warnings, problem)
+		}
+	}
+	if cfg.bitrate {
+		var err error
+		res.bitrate, err = probeBitrate(ctx, cmd, file, res.length, cfg.bitrateEvery)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dumpRaw prints ffprobe output to stderr, so it doesn't mix with the results.
+func dumpRaw(out string) {
+	fmt.Fprint(os.Stderr, out)
+	if !strings.HasSuffix(out, "\n") {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+// showEntries returns value for ffprobe's -show_entries option
+// that only includes the fields needed for cfg.
+func showEntries(cfg config) string {
+	stream := []string{}
+	tags := []string{}
+	if cfg.start || cfg.end || cfg.checkEnd || cfg.detelecineEnd || cfg.samples > 0 || cfg.quarters || cfg.timecodeStream || cfg.timecodes || cfg.chapters || cfg.trim != "" || cfg.keyframeBefore != "" || cfg.frameAt != "" || cfg.checkDrop {
+		tags = append(tags, "timecode")
+	}
+	if cfg.end || cfg.checkEnd || cfg.detelecineEnd || cfg.samples > 0 || cfg.quarters || cfg.chapters || cfg.trim != "" || cfg.keyframeBefore != "" || cfg.frameAt != "" || cfg.checkDrop || (cfg.start && cfg.computedStart()) {
+		stream = append(stream, "codec_tag_string", "avg_frame_rate")
+	}
+	if cfg.bitrate {
+		stream = append(stream, "duration")
+	}
+	if cfg.shiftStart {
+		stream = append(stream, "time_base", "start_pts", "start_time", "r_frame_rate")
+	}
+	if cfg.needsFrames() {
+		// field_order, duration and r_frame_rate are for checking nb_frames of interlaced video.
+		stream = append(stream, "nb_frames", "field_order", "duration", "r_frame_rate")
+		if cfg.countFrames {
+			stream = append(stream, "nb_read_frames")
+		}
+	}
+	if cfg.strict || cfg.chapters || cfg.checkDrop {
+		stream = append(stream, "r_frame_rate")
+	}
+	if cfg.checkTimecodeRate {
+		stream = append(stream, "codec_tag_string", "avg_frame_rate", "r_frame_rate")
+	}
+	if cfg.timecodes {
+		stream = append(stream, "avg_frame_rate", "r_frame_rate")
+	}
+	if cfg.durationDiff {
+		stream = append(stream, "codec_type", "duration")
+	}
+	if cfg.standard != "" {
+		stream = append(stream, "width", "height", "field_order", "r_frame_rate")
+	}
+	if cfg.resolution || cfg.class || cfg.limits.resolution() {
+		stream = append(stream, "width", "height")
+	}
+	if cfg.checkAspect {
+		stream = append(stream, "width", "height")
+	}
+	if cfg.resolution || cfg.checkAspect {
+		stream = append(stream, "sample_aspect_ratio")
+	}
+	if cfg.codec {
+		stream = append(stream, "codec_name", "profile", "level", "pix_fmt")
+	}
+	if cfg.colorspace {
+		stream = append(stream, "color_space")
+	}
+	if cfg.colorInfo {
+		stream = append(stream, "color_space", "color_primaries", "color_transfer")
+	}
+	if cfg.pixfmt {
+		stream = append(stream, "pix_fmt", "color_range")
+	}
+	if cfg.reel {
+		// codec_tag_string finds the tmcd track.
+		stream = append(stream, "codec_tag_string")
+	}
+	if cfg.cover {
+		stream = append(stream, "codec_name", "width", "height")
+	}
+	// index keeps every stream section printed, even if it doesn't have other fields.
+	entries := "stream=" + strings.Join(append([]string{"index"}, stream...), ",")
+	if cfg.encoder {
+		tags = append(tags, "encoder")
+	}
+	if cfg.reel {
+		tags = append(tags, "reel_name")
+	}
+	if cfg.creation {
+		tags = append(tags, "creation_time")
+	}
+	if cfg.resolution {
+		tags = append(tags, "rotate")
+	}
+	disposition := []string{}
+	if cfg.durationDiff && !cfg.cover {
+		disposition = append(disposition, "attached_pic")
+	}
+	if cfg.cover {
+		disposition = append(disposition, "attached_pic")
+	}
+	if len(tags) != 0 {
+		entries += ":stream_tags=" + strings.Join(tags, ",")
+	}
+	if len(disposition) != 0 {
+		entries += ":stream_disposition=" + strings.Join(disposition, ",")
+	}
+	sideData := []string{}
+	if cfg.stereo3D || cfg.projection {
+		sideData = append(sideData, "side_data_type")
+	}
+	if cfg.stereo3D {
+		sideData = append(sideData, "type", "inverted")
+	}
+	if cfg.projection {
+		sideData = append(sideData, "projection", "bound_left", "bound_right")
+	}
+	if cfg.resolution {
+		sideData = append(sideData, "rotation")
+	}
+	if len(sideData) != 0 {
+		entries += ":stream_side_data=" + strings.Join(sideData, ",")
+	}
+	if cfg.chapters {
+		entries += ":chapter=start_time,end_time:chapter_tags=title"
+	}
+	return entries
+}
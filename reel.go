@@ -0,0 +1,53 @@
+package movinfo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// reelFormatTags are metadata keys of the format that could have the reel name, in order.
+var reelFormatTags = []string{"com.apple.quicktime.reel", "reel_name", "reel"}
+
+// parseReel returns the reel (tape) name of the mov and where it came from.
+// ffmpeg reads the name of a tmcd track as reel_name of the track, so it is preferred
+// to the tags of the video stream and the format.
+// It returns "none" when the mov doesn't have it.
+func parseReel(streams []string, videoStream string, formatTags map[string]string) (string, string) {
+	for i, stream := range streams {
+		isTmcd := false
+		reel := ""
+		index := i
+		for r := (lineReader{s: stream}); r.next(); {
+			l := r.line
+			if strings.HasPrefix(l, "index=") {
+				if n, err := strconv.Atoi(strings.TrimPrefix(l, "index=")); err == nil {
+					index = n
+				}
+			}
+			if l == "codec_tag_string=tmcd" {
+				isTmcd = true
+			}
+			if strings.HasPrefix(l, "TAG:reel_name=") && reel == "" {
+				reel = strings.TrimSpace(strings.TrimPrefix(l, "TAG:reel_name="))
+			}
+		}
+		if isTmcd && reel != "" {
+			return reel, fmt.Sprintf("TAG:reel_name of tmcd stream %v", index)
+		}
+	}
+	for r := (lineReader{s: videoStream}); r.next(); {
+		l := r.line
+		if strings.HasPrefix(l, "TAG:reel_name=") {
+			if reel := strings.TrimSpace(strings.TrimPrefix(l, "TAG:reel_name=")); reel != "" {
+				return reel, "TAG:reel_name of the video stream"
+			}
+		}
+	}
+	for _, k := range reelFormatTags {
+		if reel := strings.TrimSpace(formatTags[k]); reel != "" {
+			return reel, k + " of the format"
+		}
+	}
+	return "none", "none"
+}
@@ -0,0 +1,129 @@
+package movinfo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// resolutionClass is a standard resolution bucket.
+type resolutionClass struct {
+	label  string
+	width  int
+	height int
+}
+
+// resolutionClasses are standard resolutions from the biggest one.
+// DCI resolutions come before UHD/HD, since they are checked by width.
+var resolutionClasses = []resolutionClass{
+	{"UHD-8K", 7680, 4320},
+	{"DCI-4K", 4096, 2160},
+	{"UHD-4K", 3840, 2160},
+	{"DCI-2K", 2048, 1080},
+	{"HD", 1920, 1080},
+	{"HD-720", 1280, 720},
+	{"SD", 720, 576},
+}
+
+// resolutionTolerance is how much a size can be off from a standard size
+// and still be classified into it, in ratio.
+const resolutionTolerance = 0.02
+
+// classifyResolution classifies width and height into a standard bucket
+// such as "HD", "UHD-4K" or "DCI-4K". Width is matched first, because
+// cropped (scope, flat) images keep the width but not the height.
+// It returns "SD" for images smaller than HD-720, and "" for zero size.
+func classifyResolution(width, height int) string {
+	if width <= 0 || height <= 0 {
+		return ""
+	}
+	for _, c := range resolutionClasses {
+		if near(width, c.width) && height <= c.height+int(float64(c.height)*resolutionTolerance) {
+			return c.label
+		}
+	}
+	// check from the smallest, so 1440x1080 is HD but 1998x1080 is DCI-2K.
+	for i := len(resolutionClasses) - 1; i >= 0; i-- {
+		c := resolutionClasses[i]
+		if near(height, c.height) && width <= c.width {
+			return c.label
+		}
+	}
+	// non-standard size, find the biggest bucket it fills.
+	for _, c := range resolutionClasses {
+		if width >= c.width {
+			return c.label
+		}
+	}
+	return "SD"
+}
+
+// near reports whether n is within resolutionTolerance of std.
+func near(n, std int) bool {
+	d := n - std
+	if d < 0 {
+		d = -d
+	}
+	return float64(d) <= float64(std)*resolutionTolerance
+}
+
+// parseSize parses width and height of a stream, which should be positive integers.
+// ffprobe could report N/A or 0 for a broken stream.
+func parseSize(width, height string) (int, int, error) {
+	if width == "" {
+		return 0, 0, ErrMissingWidth
+	}
+	if height == "" {
+		return 0, 0, ErrMissingHeight
+	}
+	w, err := strconv.Atoi(width)
+	if err != nil || w <= 0 {
+		return 0, 0, fmt.Errorf("%w: width=%v", ErrInvalidSize, width)
+	}
+	h, err := strconv.Atoi(height)
+	if err != nil || h <= 0 {
+		return 0, 0, fmt.Errorf("%w: height=%v", ErrInvalidSize, height)
+	}
+	return w, h, nil
+}
+
+// displaySize returns the size of the video as it is displayed, after applying
+// sample aspect ratio (ex. 4:3) to the width and rotation in degrees.
+func displaySize(width, height int, sar string, rotation int) (int, int) {
+	num, den, ok := strings.Cut(sar, ":")
+	if ok {
+		n, nerr := strconv.Atoi(num)
+		d, derr := strconv.Atoi(den)
+		// 0:1 means unknown.
+		if nerr == nil && derr == nil && n > 0 && d > 0 {
+			width = int(math.Round(float64(width) * float64(n) / float64(d)))
+		}
+	}
+	rotation = (rotation%360 + 360) % 360
+	if rotation == 90 || rotation == 270 {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// parseRotation parses rotation of a stream in degrees, from its display matrix
+// side data, or rotate tag that old ffprobe shows. It returns 0 when there isn't.
+func parseRotation(stream string) int {
+	for r := (lineReader{s: stream}); r.next(); {
+		if strings.HasPrefix(r.line, "rotation=") {
+			// rotation is often like -90.00.
+			f, err := strconv.ParseFloat(strings.TrimPrefix(r.line, "rotation="), 64)
+			if err == nil {
+				return int(math.Round(f))
+			}
+		}
+		if strings.HasPrefix(r.line, "TAG:rotate=") {
+			n, err := strconv.Atoi(strings.TrimPrefix(r.line, "TAG:rotate="))
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,171 @@
+package movinfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// scanFrames is number of frames -scan-type reads from the start of the video.
+// It is enough for dozens of pulldown cycles, and fast even for long movs.
+const scanFrames = 120
+
+// scanArgs are ffprobe arguments to show interlace flags of the first scanFrames
+// video frames, one frame per line. (ex. interlaced_frame=1|top_field_first=1|repeat_pict=0)
+// compact keeps the keys, as the order of the fields differs between ffprobe versions.
+var scanArgs = []string{"-select_streams", "v:0", "-read_intervals", "%+#" + strconv.Itoa(scanFrames),
+	"-show_entries", "frame=interlaced_frame,top_field_first,repeat_pict", "-of", "compact=p=0"}
+
+// scanFrame is interlace flags of a video frame.
+type scanFrame struct {
+	interlaced bool
+	tff        bool
+	// repeat is repeat_pict, that is how many fields the frame is extended.
+	// soft telecine repeats a field every other frame.
+	repeat int
+}
+
+// probeScanType runs ffprobe for interlace flags of the first frames of the video,
+// and returns its scan type.
+func probeScanType(ctx context.Context, cmd []string, file string) (string, error) {
+	var frames []scanFrame
+	err := probeStream(ctx, cmd, file, scanArgs, func(r io.Reader) error {
+		var err error
+		frames, err = readScanFrames(r)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return detectScanType(frames), nil
+}
+
+// readScanFrames reads frames of scanArgs format.
+func readScanFrames(r io.Reader) ([]scanFrame, error) {
+	frames := []scanFrame{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" {
+			continue
+		}
+		f := scanFrame{}
+		for _, kv := range strings.Split(l, "|") {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "interlaced_frame":
+				f.interlaced = v == "1"
+			case "top_field_first":
+				f.tff = v == "1"
+			case "repeat_pict":
+				f.repeat, _ = strconv.Atoi(v)
+			}
+		}
+		frames = append(frames, f)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no video frames")
+	}
+	return frames, nil
+}
+
+// detectScanType tells scan type of the frames. 3:2 pulldown is found either as
+// soft telecine, that repeats a field in 2 of every 4 progressive frames,
+// or as hard telecine flagged per frame, with 2 interlaced frames in every 5.
+// Hard telecine encoded as all interlaced can't be told from the flags, and
+// is reported as interlaced.
+func detectScanType(frames []scanFrame) string {
+	repeat := make([]bool, len(frames))
+	interlaced := make([]bool, len(frames))
+	nInterlaced := 0
+	nTFF := 0
+	for i, f := range frames {
+		repeat[i] = f.repeat > 0
+		interlaced[i] = f.interlaced
+		if f.interlaced {
+			nInterlaced++
+			if f.tff {
+				nTFF++
+			}
+		}
+	}
+	switch {
+	case pulldown(repeat, 4, 2) || pulldown(interlaced, 5, 2):
+		return "telecine (3:2 pulldown)"
+	case nInterlaced == 0:
+		return "progressive"
+	case nInterlaced == len(frames) && nTFF == len(frames):
+		return "interlaced (top field first)"
+	case nInterlaced == len(frames) && nTFF == 0:
+		return "interlaced (bottom field first)"
+	case nInterlaced == len(frames):
+		return "interlaced"
+	}
+	return "mixed"
+}
+
+// pulldown reports whether flags are set in n of every period frames, for
+// most of the frames. A few frames could break the pattern at cuts.
+func pulldown(flags []bool, period, n int) bool {
+	if len(flags) < 2*period {
+		return false
+	}
+	// count how many windows of period frames have n frames set.
+	windows := len(flags) - period + 1
+	match := 0
+	set := 0
+	for i, f := range flags {
+		if f {
+			set++
+		}
+		if i >= period && flags[i-period] {
+			set--
+		}
+		if i >= period-1 && set == n {
+			match++
+		}
+	}
+	return float64(match) >= 0.9*float64(windows)
+}
+
+// telecine is the literal end of a 29.97 mov and its end in 23.976 after pulldown
+// removal, for -detelecine-end. filmEnd is empty when the rate can't have pulldown.
+type telecine struct {
+	end     string
+	filmEnd string
+	rate    string
+}
+
+// filmEnd returns the end of film frames that are telecined to frames from the start
+// in base 30. 3:2 pulldown makes 5 frames of every 4 film frames, and the film start
+// keeps hours, minutes and seconds of the start, with its frames scaled to base 24.
+func filmEnd(start *Timecode, frames int, cfg config) (*Timecode, error) {
+	ff, err := strconv.Atoi(strings.TrimPrefix(start.Format("FF"), "-"))
+	if err != nil {
+		return nil, err
+	}
+	film, err := NewTimecode(fmt.Sprintf("%v:%02d", start.Format("HH:MM:SS"), ff*24/start.Base()), 24, false)
+	if err != nil {
+		return nil, err
+	}
+	film.Add(cfg.roundFrames(float64(frames)*4/5) - 1)
+	return film, nil
+}
+
+// format formats the telecine for the scan type of the mov.
+// (ex. literal 01:02:00;03, detelecined 01:01:59:23)
+func (t telecine) format(scanType string) string {
+	if t.filmEnd == "" {
+		return fmt.Sprintf("literal %v, not telecine (%v fps)", t.end, t.rate)
+	}
+	if !strings.HasPrefix(scanType, "telecine") {
+		return fmt.Sprintf("literal %v, not telecine (%v)", t.end, scanType)
+	}
+	return fmt.Sprintf("literal %v, detelecined %v", t.end, t.filmEnd)
+}
@@ -0,0 +1,171 @@
+package movinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// clip is a mov with its start and end timecode.
+type clip struct {
+	file  string
+	start *Timecode
+	end   *Timecode
+}
+
+// newClip creates a clip from the result that has start and end of the mov.
+func newClip(file string, res result) (clip, error) {
+	// start from the tag might not have the semicolon, trust the computed end.
+	drop := strings.Contains(res.end, ";")
+	start, err := newTimecode(res.start, res.base, drop)
+	if err != nil {
+		return clip{}, fmt.Errorf("%v: %w", file, err)
+	}
+	end, err := newTimecode(res.end, res.base, drop)
+	if err != nil {
+		return clip{}, fmt.Errorf("%v: %w", file, err)
+	}
+	return clip{file: file, start: start, end: end}, nil
+}
+
+// checkSequence probes the files and reports gaps or overlaps between them.
+// See sequenceGaps for the report.
+func checkSequence(files []string, cfg config) ([]sequenceGap, error) {
+	clips, err := probeClips(files, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sequenceGaps(clips)
+}
+
+// checkSegments probes the files, that are segments of a reel in order,
+// and reports whether their timecode chains. See segmentChain for the report.
+func checkSegments(files []string, cfg config) (string, error) {
+	clips, err := probeClips(files, cfg)
+	if err != nil {
+		return "", err
+	}
+	return segmentChain(clips)
+}
+
+// probeClips probes the files for their start and end timecode.
+func probeClips(files []string, cfg config) ([]clip, error) {
+	cfg.start = true
+	cfg.end = true
+	// newClip needs start and end in timecode.
+	cfg.layout = ""
+	cfg.seconds = false
+	cfg.midnightFrames = false
+	cfg.exclusiveEnd = false
+	clips := make([]clip, 0, len(files))
+	for _, f := range files {
+		res, err := probeFile(f, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", f, err)
+		}
+		c, err := newClip(f, res)
+		if err != nil {
+			return nil, err
+		}
+		clips = append(clips, c)
+	}
+	return clips, nil
+}
+
+// sequenceGap is a gap or an overlap between two consecutive clips of -sequence.
+// Kind is one of "ok", "gap" or "overlap", and Frames is its number of frames.
+type sequenceGap struct {
+	File   string `json:"file"`
+	Next   string `json:"next"`
+	Kind   string `json:"kind"`
+	Frames int    `json:"frames"`
+}
+
+// String returns the gap as a line like
+//
+//	a.mov	b.mov	gap	12
+func (g sequenceGap) String() string {
+	return fmt.Sprintf("%v\t%v\t%v\t%v", g.File, g.Next, g.Kind, g.Frames)
+}
+
+// sequenceGaps sorts clips by their start and reports gaps or overlaps
+// between each consecutive clips. clips isn't changed.
+func sequenceGaps(clips []clip) ([]sequenceGap, error) {
+	if len(clips) == 0 {
+		return nil, nil
+	}
+	base := clips[0].start.Base()
+	for _, c := range clips {
+		if c.start.Base() != base {
+			return nil, fmt.Errorf("timecode base of %v and %v are different", clips[0].file, c.file)
+		}
+	}
+	clips = append([]clip{}, clips...)
+	sort.SliceStable(clips, func(i, j int) bool {
+		return clips[i].start.Frames() < clips[j].start.Frames()
+	})
+	gaps := make([]sequenceGap, 0, len(clips)-1)
+	for i := 1; i < len(clips); i++ {
+		prev := clips[i-1]
+		next := clips[i]
+		n := next.start.Frames() - (prev.end.Frames() + 1)
+		kind := "ok"
+		if n > 0 {
+			kind = "gap"
+		} else if n < 0 {
+			kind = "overlap"
+			n = -n
+		}
+		gaps = append(gaps, sequenceGap{File: prev.file, Next: next.file, Kind: kind, Frames: n})
+	}
+	return gaps, nil
+}
+
+// printSequence prints the gaps one per line, or as json objects with asJSON.
+func printSequence(w io.Writer, gaps []sequenceGap, asJSON bool) error {
+	for _, g := range gaps {
+		if !asJSON {
+			fmt.Fprintln(w, g)
+			continue
+		}
+		b, err := json.Marshal(g)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, string(b))
+	}
+	return nil
+}
+
+// segmentChain checks the clips in the given order chain continuously,
+// so that each clip starts at the frame after the end of the previous one.
+// It reports the whole range when they do, like
+//
+//	continuous	01:00:00;00	01:00:30;00
+//
+// or the first discontinuity with the expected and the actual start.
+//
+//	discontinuous	a.mov	b.mov	expected 01:00:10;00	got 01:00:12;00
+func segmentChain(clips []clip) (string, error) {
+	if len(clips) == 0 {
+		return "", nil
+	}
+	for _, c := range clips {
+		if c.start.Base() != clips[0].start.Base() || c.start.Drop() != clips[0].start.Drop() {
+			return "", fmt.Errorf("timecode system of %v and %v are different", clips[0].file, c.file)
+		}
+	}
+	for i := 1; i < len(clips); i++ {
+		prev := clips[i-1]
+		next := clips[i]
+		expected := *prev.end
+		// Add skips the frames drop frame timecode doesn't have.
+		expected.Add(1)
+		if Diff(&expected, next.start) != 0 {
+			return fmt.Sprintf("discontinuous\t%v\t%v\texpected %v\tgot %v", prev.file, next.file, expected.String(), next.start.String()), nil
+		}
+	}
+	return fmt.Sprintf("continuous\t%v\t%v", clips[0].start, clips[len(clips)-1].end), nil
+}
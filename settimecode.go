@@ -0,0 +1,136 @@
+package movinfo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// setTimecodeArgs are ffmpeg arguments that copy the file to out with the timecode.
+// The streams are copied without re-encoding, except the old tmcd tracks at the
+// stream indexes tmcd, as the mov muxer writes a new tmcd track for the timecode metadata.
+// Other data streams are copied. ffmpeg doesn't overwrite out, unless force.
+func setTimecodeArgs(file, out, timecode string, tmcd []string, force bool) []string {
+	overwrite := "-n"
+	if force {
+		overwrite = "-y"
+	}
+	args := []string{"-nostdin", "-hide_banner", overwrite, "-i", file, "-map", "0"}
+	for _, index := range tmcd {
+		args = append(args, "-map", "-0:"+index)
+	}
+	return append(args, "-c", "copy", "-metadata", "timecode="+timecode, out)
+}
+
+// tmcdStreamsArgs are ffprobe arguments for finding the tmcd tracks.
+var tmcdStreamsArgs = []string{"-v", "error", "-show_entries", "stream=index,codec_tag_string"}
+
+// parseTmcdIndexes returns stream indexes of the tmcd tracks in ffprobe output of tmcdStreamsArgs.
+func parseTmcdIndexes(data string) []string {
+	indexes := []string{}
+	for _, stream := range strings.SplitAfter(data, "[/STREAM]") {
+		if tmcd := findTmcd([]string{stream}); tmcd.index != -1 {
+			indexes = append(indexes, strconv.Itoa(tmcd.index))
+		}
+	}
+	return indexes
+}
+
+// checkSetTimecodeOut checks out isn't the file, as -set-timecode never rewrites it in place.
+func checkSetTimecodeOut(file, out string) error {
+	if out == "" {
+		return errors.New("-set-timecode needs -set-timecode-out")
+	}
+	a, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	b, err := filepath.Abs(out)
+	if err != nil {
+		return err
+	}
+	if a == b {
+		return fmt.Errorf("-set-timecode-out is the mov itself: %v", out)
+	}
+	if sa, err := os.Stat(a); err == nil {
+		if sb, err := os.Stat(b); err == nil && os.SameFile(sa, sb) {
+			return fmt.Errorf("-set-timecode-out is the mov itself: %v", out)
+		}
+	}
+	return nil
+}
+
+// setTimecodeConfig returns the config that probes the start of a mov as if it were
+// the timecode, for checking the timecode against the rate of the mov like -start-from.
+// It only has the flags of ffprobe and of the rate from cfg. The timecode is written
+// as HH:MM:SS:FF, whatever -layout is.
+func setTimecodeConfig(cfg config, timecode string) config {
+	return config{
+		start:                true,
+		startFrom:            timecode,
+		startFromFlag:        "-set-timecode",
+		ffprobe:              cfg.ffprobe,
+		ffprobeArgs:          cfg.ffprobeArgs,
+		preExec:              cfg.preExec,
+		retries:              cfg.retries,
+		timeout:              cfg.timeout,
+		framerate:            cfg.framerate,
+		dropMode:             cfg.dropMode,
+		forceDrop:            cfg.forceDrop,
+		strictTimecodeLength: cfg.strictTimecodeLength,
+	}
+}
+
+// setTimecodeCommand returns the ffmpeg command of setTimecodeArgs.
+func setTimecodeCommand(ffmpeg, file, out, timecode string, tmcd []string, force bool) []string {
+	if ffmpeg == "" {
+		ffmpeg = "ffmpeg"
+	}
+	return append([]string{ffmpeg}, setTimecodeArgs(file, out, timecode, tmcd, force)...)
+}
+
+// setTimecode checks the timecode against the rate of the file, and writes
+// the file with it to out with ffmpeg. It returns the timecode written.
+// With -pre-exec, ffmpeg reads the path it opened, the same one probed.
+func setTimecode(file, out, timecode string, cfg config, force bool) (string, error) {
+	if err := checkSetTimecodeOut(file, out); err != nil {
+		return "", err
+	}
+	ctx, cancel := cfg.probeContext()
+	defer cancel()
+	path, cleanup, err := openFile(file, cfg.opener(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	if path != file {
+		if err := checkSetTimecodeOut(path, out); err != nil {
+			return "", err
+		}
+	}
+	c := setTimecodeConfig(cfg, timecode)
+	// the path is already opened.
+	c.preExec = ""
+	res, err := probeFile(path, c)
+	if err != nil {
+		return "", err
+	}
+	data, err := probe(ctx, cfg.ffprobeCmd(), path, tmcdStreamsArgs...)
+	if err != nil {
+		return "", err
+	}
+	argv := setTimecodeCommand(cfg.ffmpeg, path, out, res.start, parseTmcdIndexes(data), force)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	if b, err := cmd.CombinedOutput(); err != nil {
+		if len(b) == 0 {
+			return "", fmt.Errorf("couldn't write %v: %w", out, err)
+		}
+		return "", fmt.Errorf("couldn't write %v: %v", out, lastLine(string(b)))
+	}
+	return res.start, nil
+}
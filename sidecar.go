@@ -0,0 +1,110 @@
+package movinfo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sidecarExt is added to the name of a mov for its sidecar. (ex. a.mov.movinfo.json)
+const sidecarExt = ".movinfo.json"
+
+// sidecarPath returns path of the sidecar of the file.
+func sidecarPath(file string) string {
+	return file + sidecarExt
+}
+
+// sidecarFresh reports whether the sidecar of the file exists and is newer than the file,
+// so it doesn't need to be written again.
+func sidecarFresh(file string) (bool, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	si, err := os.Stat(sidecarPath(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return si.ModTime().After(fi.ModTime()), nil
+}
+
+// writeSidecar writes info as json to the sidecar of the file.
+// It writes to a temporary file first, so the sidecar is never half written.
+func writeSidecar(file string, info *Info) error {
+	b, err := json.MarshalIndent(info, "", "\t")
+	if err != nil {
+		return err
+	}
+	path := sidecarPath(file)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp makes it only readable by the owner, but a sidecar is
+	// read by others like the mov.
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	// file systems could stamp it with a coarse clock, that is older than
+	// a mov written just before. sidecarFresh needs it to be newer.
+	now := time.Now()
+	if err := os.Chtimes(tmp.Name(), now, now); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// sidecar probes the file for all the information, and writes it to the sidecar.
+// It skips the file when the sidecar is fresh, unless force is true.
+// It returns path of the sidecar, or "" when it skipped.
+func sidecar(file string, cfg config, force bool) (string, error) {
+	if !force {
+		fresh, err := sidecarFresh(file)
+		if err != nil {
+			return "", err
+		}
+		if fresh {
+			return "", nil
+		}
+	}
+	info, err := probeAll(file, cfg)
+	if err != nil {
+		return "", err
+	}
+	warn(info.Warnings)
+	if err := writeSidecar(file, info); err != nil {
+		return "", err
+	}
+	return sidecarPath(file), nil
+}
+
+// writeSidecarOf writes the sidecar of the file, and prints its path to w,
+// or that it is skipped to stderr.
+func writeSidecarOf(w io.Writer, file string, cfg config, force bool) error {
+	path, err := sidecar(file, cfg, force)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		log.Printf("skipped %v: sidecar is newer than it", file)
+		return nil
+	}
+	fmt.Fprintln(w, path)
+	return nil
+}
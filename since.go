@@ -0,0 +1,29 @@
+package movinfo
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// parseSince parses value of -since, which is either a duration before now (ex. 24h),
+// or a RFC 3339 timestamp (ex. 2024-05-01T00:00:00+09:00).
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid -since, need a duration or RFC 3339 timestamp: %v", s)
+	}
+	return t, nil
+}
+
+// modifiedSince reports whether the file is modified at or after since.
+func modifiedSince(file string, since time.Time) (bool, error) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	return !fi.ModTime().Before(since), nil
+}
@@ -0,0 +1,102 @@
+package movinfo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// broadcastFormat is a combination of resolution class, scan and frame rate
+// that a broadcast standard allows.
+type broadcastFormat struct {
+	class      string
+	interlaced bool
+	// num and den are the frame rate, not the field rate of interlaced video.
+	num int
+	den int
+}
+
+// String formats the broadcastFormat. (ex. HD interlaced 25)
+func (f broadcastFormat) String() string {
+	scan := "progressive"
+	if f.interlaced {
+		scan = "interlaced"
+	}
+	return fmt.Sprintf("%v %v %v", f.class, scan, FrameRate{Num: f.num, Den: f.den})
+}
+
+// standards are formats of -standard by its name. ebu ones are of 50 Hz countries,
+// and atsc and ntsc ones are of 60 Hz countries.
+var standards = map[string][]broadcastFormat{
+	"ebu-sd": {
+		{"SD", true, 25, 1},
+	},
+	"ebu-hd": {
+		{"HD", true, 25, 1},
+		{"HD", false, 25, 1},
+		{"HD", false, 50, 1},
+		{"HD-720", false, 50, 1},
+	},
+	"ebu-uhd": {
+		{"UHD-4K", false, 25, 1},
+		{"UHD-4K", false, 50, 1},
+	},
+	"ntsc-sd": {
+		{"SD", true, 30000, 1001},
+	},
+	"atsc-hd": {
+		{"HD", true, 30000, 1001},
+		{"HD", true, 30, 1},
+		{"HD", false, 24000, 1001},
+		{"HD", false, 24, 1},
+		{"HD", false, 30000, 1001},
+		{"HD", false, 30, 1},
+		{"HD-720", false, 24000, 1001},
+		{"HD-720", false, 24, 1},
+		{"HD-720", false, 30000, 1001},
+		{"HD-720", false, 30, 1},
+		{"HD-720", false, 60000, 1001},
+		{"HD-720", false, 60, 1},
+	},
+	"atsc-uhd": {
+		{"UHD-4K", false, 24000, 1001},
+		{"UHD-4K", false, 24, 1},
+		{"UHD-4K", false, 30000, 1001},
+		{"UHD-4K", false, 30, 1},
+		{"UHD-4K", false, 60000, 1001},
+		{"UHD-4K", false, 60, 1},
+	},
+}
+
+// standardNames returns names of the standards in order.
+func standardNames() []string {
+	names := make([]string, 0, len(standards))
+	for name := range standards {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkStandard checks the video of the resolution class, field order and frame rate
+// is one of the formats of the standard. Scan is only checked when the field order
+// is known. It returns the problem, or "" when there isn't.
+func checkStandard(name, class, fieldOrder string, rate FrameRate) string {
+	formats := standards[name]
+	interlaced := fieldOrder != "progressive"
+	known := fieldOrder != "" && fieldOrder != "unknown"
+	for _, f := range formats {
+		if f.class == class && f.num == rate.Num && f.den == rate.Den && (!known || f.interlaced == interlaced) {
+			return ""
+		}
+	}
+	video := broadcastFormat{class: class, interlaced: interlaced, num: rate.Num, den: rate.Den}.String()
+	if !known {
+		video = fmt.Sprintf("%v %v", class, rate)
+	}
+	allowed := []string{}
+	for _, f := range formats {
+		allowed = append(allowed, f.String())
+	}
+	return fmt.Sprintf("%v doesn't conform to %v (%v)", video, name, strings.Join(allowed, ", "))
+}
@@ -0,0 +1,80 @@
+package movinfo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StreamInfo is information of a stream in a mov.
+// Fields that don't apply to the type of the stream are empty,
+// ex) Channels of a video stream.
+type StreamInfo struct {
+	Index int `json:"index"`
+	// Type is codec_type of the stream. (ex. video, audio, subtitle, data)
+	Type    string `json:"type"`
+	Codec   string `json:"codec"`
+	Profile string `json:"profile"`
+	Width   int    `json:"width"`
+	Height  int    `json:"height"`
+	// Rate is avg_frame_rate of the stream, as ffprobe prints it. (ex. 24000/1001)
+	Rate       string `json:"rate"`
+	SampleRate int    `json:"sample_rate"`
+	Channels   int    `json:"channels"`
+	Layout     string `json:"layout"`
+	Language   string `json:"language"`
+	// AttachedPic is whether the stream is a cover image rather than a video.
+	AttachedPic bool `json:"attached_pic"`
+	// Tags are all TAG: lines of the stream, without the prefix.
+	Tags map[string]string `json:"tags"`
+}
+
+// parseStreamInfos parses [STREAM] sections of ffprobe output for all the streams.
+// Values ffprobe couldn't get, like N/A and unknown, are left empty.
+func parseStreamInfos(data string) []StreamInfo {
+	infos := []StreamInfo{}
+	sects := strings.Split(data, "[STREAM]")
+	for _, sect := range sects[1:] {
+		sect, _, _ = strings.Cut(sect, "[/STREAM]")
+		s := StreamInfo{Index: -1, Tags: map[string]string{}}
+		for r := (lineReader{s: sect}); r.next(); {
+			k, v, ok := strings.Cut(r.line, "=")
+			if !ok || v == "N/A" || v == "unknown" {
+				continue
+			}
+			if strings.HasPrefix(k, "TAG:") {
+				s.Tags[strings.TrimPrefix(k, "TAG:")] = v
+				continue
+			}
+			n, _ := strconv.Atoi(v)
+			switch k {
+			case "index":
+				s.Index = n
+			case "codec_type":
+				s.Type = v
+			case "codec_name":
+				s.Codec = v
+			case "profile":
+				s.Profile = v
+			case "width":
+				s.Width = n
+			case "height":
+				s.Height = n
+			case "avg_frame_rate":
+				if v != "0/0" {
+					s.Rate = v
+				}
+			case "sample_rate":
+				s.SampleRate = n
+			case "channels":
+				s.Channels = n
+			case "channel_layout":
+				s.Layout = v
+			case "DISPOSITION:attached_pic":
+				s.AttachedPic = v == "1"
+			}
+		}
+		s.Language = s.Tags["language"]
+		infos = append(infos, s)
+	}
+	return infos
+}
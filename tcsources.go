@@ -0,0 +1,76 @@
+package movinfo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sources of the start timecode for -timecode-order.
+const (
+	// tcVideo is the timecode tag of the video stream.
+	tcVideo = "video"
+	// tcStreams is the timecode tag of the first other stream that has one, like the tmcd track.
+	tcStreams = "streams"
+	// tcFormat is the timecode tag of the format.
+	tcFormat = "format"
+	// tcTmcdFrame is the frame number in the sample of the tmcd track. It needs
+	// another ffprobe run, so it is only read when the tags don't have a timecode.
+	tcTmcdFrame = "tmcd-frame"
+)
+
+// defaultTimecodeOrder is the order of the sources movinfo has always followed.
+// Timecode of the streams comes before the one of the format, as it is what
+// the editing applications read.
+const defaultTimecodeOrder = "video,streams,format,tmcd-frame"
+
+// parseTimecodeOrder parses comma separated sources of -timecode-order.
+// tmcd-frame could only be the last.
+func parseTimecodeOrder(s string) ([]string, error) {
+	sources := []string{}
+	seen := map[string]bool{}
+	for _, src := range strings.Split(s, ",") {
+		src = strings.TrimSpace(src)
+		switch src {
+		case tcVideo, tcStreams, tcFormat, tcTmcdFrame:
+		default:
+			return nil, fmt.Errorf("unknown timecode source: %v", src)
+		}
+		if seen[src] {
+			return nil, fmt.Errorf("duplicate timecode source: %v", src)
+		}
+		if seen[tcTmcdFrame] {
+			return nil, fmt.Errorf("%v should be the last timecode source", tcTmcdFrame)
+		}
+		seen[src] = true
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// timecodeSources returns the sources of the start timecode in order of preference.
+// -video-timecode-only only has the video stream.
+func (c config) timecodeSources() []string {
+	if c.videoTimecodeOnly {
+		return []string{tcVideo}
+	}
+	order := c.timecodeOrder
+	if order == "" {
+		order = defaultTimecodeOrder
+	}
+	sources, err := parseTimecodeOrder(order)
+	if err != nil {
+		// main checks -timecode-order, so it is only for a broken config in tests.
+		sources, _ = parseTimecodeOrder(defaultTimecodeOrder)
+	}
+	return sources
+}
+
+// hasTimecodeSource reports whether the source is one of the sources of c.
+func (c config) hasTimecodeSource(src string) bool {
+	for _, s := range c.timecodeSources() {
+		if s == src {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package movinfo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kzmdstu/movinfo/timecode"
+)
+
+// Timecode and FrameRate are in package timecode, so that they could be used
+// without the rest of movinfo. These keep the names movinfo has used.
+type (
+	Timecode  = timecode.Timecode
+	FrameRate = timecode.FrameRate
+)
+
+// NewTimecode creates new Timecode. See timecode.New.
+func NewTimecode(code string, base int, drop bool) (*Timecode, error) {
+	return timecode.New(code, base, drop)
+}
+
+// NewTimecodeRate creates new Timecode in the frame rate.
+func NewTimecodeRate(code string, rate FrameRate) (*Timecode, error) {
+	return timecode.NewRate(code, rate)
+}
+
+// NewTimecodeForceDrop creates new drop frame Timecode even for base 24.
+func NewTimecodeForceDrop(code string, base int) (*Timecode, error) {
+	return timecode.NewForceDrop(code, base)
+}
+
+// NewTimecodeFromFrames creates new Timecode from number of frames. See timecode.FromFrames.
+func NewTimecodeFromFrames(frames, base int, drop bool) (*Timecode, error) {
+	return timecode.FromFrames(frames, base, drop)
+}
+
+// newTimecode creates new Timecode as drop says, even drop frame for base 24.
+func newTimecode(code string, base int, drop bool) (*Timecode, error) {
+	if drop {
+		return timecode.NewForceDrop(code, base)
+	}
+	return timecode.New(code, base, false)
+}
+
+// LookupFrameRate finds a known frame rate. See timecode.LookupFrameRate.
+func LookupFrameRate(rational string) (FrameRate, bool) {
+	return timecode.LookupFrameRate(rational)
+}
+
+// Diff returns number of frames from a to b. See timecode.Diff.
+func Diff(a, b *Timecode) int {
+	return timecode.Diff(a, b)
+}
+
+// lookupFPS finds a known frame rate by fps of the overview. See timecode.LookupFPS.
+func lookupFPS(fps string) (FrameRate, bool) {
+	return timecode.LookupFPS(fps)
+}
+
+// knownBase reports whether a timecode base is used by any known frame rate.
+func knownBase(base int) bool {
+	return timecode.KnownBase(base)
+}
+
+// validateTimecode checks the timecode code is valid in the base and drop system.
+func validateTimecode(code string, base int, drop bool) error {
+	return timecode.Validate(code, base, drop)
+}
+
+// quarterPercents are labels of the Timecodes of timecode.Quarters.
+var quarterPercents = [5]int{0, 25, 50, 75, 100}
+
+// quarterPoints returns timecodes of timecode.Quarters of a clip from start that has frames.
+func (c config) quarterPoints(start *Timecode, frames int) [5]string {
+	var points [5]string
+	for i, tc := range timecode.Quarters(start, frames) {
+		points[i] = c.formatTimecode(tc)
+	}
+	return points
+}
+
+// timecodeTag is a timecode tag that isn't exactly HH:MM:SS:FF, like 1:00:00;00 some tools write.
+var timecodeTag = regexp.MustCompile(`^(-?)(\d{1,2})([:;])(\d{1,2})([:;])(\d{1,2})([:;.])(\d{1,2})$`)
+
+// cleanTimecodeTag trims whitespace around a timecode tag, and pads its fields to two digits.
+// The tag is returned as it is when it isn't a timecode, to fail later with it.
+// With strict, the tag is always returned as it is.
+func cleanTimecodeTag(tag string, strict bool) string {
+	if strict {
+		return tag
+	}
+	m := timecodeTag.FindStringSubmatch(strings.TrimSpace(tag))
+	if m == nil {
+		return tag
+	}
+	pad := func(s string) string {
+		if len(s) == 1 {
+			return "0" + s
+		}
+		return s
+	}
+	return m[1] + pad(m[2]) + m[3] + pad(m[4]) + m[5] + pad(m[6]) + m[7] + pad(m[8])
+}
+
+// checkDrop checks the timecode code follows the drop frame rules of the rate.
+// Drop frame rates should use semicolon before the frame field, and shouldn't have
+// a frame that drop frame system skips. Other rates should use colon.
+// It returns the problem, or "" when there isn't.
+func checkDrop(code string, rate FrameRate) string {
+	code = strings.TrimPrefix(code, "-")
+	if len(code) != 11 {
+		return fmt.Sprintf("invalid timecode %v", code)
+	}
+	drop := code[8] == ';'
+	if !rate.Drop {
+		if drop {
+			return fmt.Sprintf("%v uses drop frame notation, but %v fps isn't a drop frame rate", code, rate)
+		}
+		return ""
+	}
+	if !drop {
+		return fmt.Sprintf("%v uses non-drop frame notation for %v fps, which is usually drop frame", code, rate)
+	}
+	// Normalize moves a frame that drop frame system skips.
+	if tc, err := timecode.NewForceDrop(code, rate.Base); err == nil && tc.Normalize() {
+		return fmt.Sprintf("%v is a frame that drop frame timecode skips", code)
+	}
+	return ""
+}
+
+// lookupRefRate finds the frame rate of -ref-rate, that is either
+// a rational rate (ex. 24000/1001) or fps (ex. 23.976).
+func lookupRefRate(s string) (FrameRate, bool) {
+	if rate, ok := LookupFrameRate(s); ok {
+		return rate, true
+	}
+	return lookupFPS(s)
+}
@@ -0,0 +1,114 @@
+package timecode_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kzmdstu/movinfo/timecode"
+)
+
+func ExampleNew() {
+	tc, err := timecode.New("01:00:00;00", 30, true)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(tc, tc.Frames())
+	// Output: 01:00:00;00 107892
+}
+
+func ExampleNewRate() {
+	rate, ok := timecode.LookupFrameRate("24000/1001")
+	if !ok {
+		panic("unknown rate")
+	}
+	tc, err := timecode.NewRate("00:59:59:00", rate)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(tc, rate, tc.Drop())
+	// Output: 00:59:59:00 23.98 false
+}
+
+func ExampleNewNonstandard() {
+	// 120 fps of high speed capture.
+	tc, err := timecode.NewNonstandard("00:00:59:119", 120)
+	if err != nil {
+		panic(err)
+	}
+	tc.Add(1)
+	fmt.Println(tc, tc.Frames())
+	// Output: 00:01:00:000 7200
+}
+
+func ExampleTimecode_Add() {
+	tc, _ := timecode.New("00:00:59;29", 30, true)
+	// 00:01:00;00 and 00:01:00;01 are skipped.
+	tc.Add(1)
+	fmt.Println(tc)
+	tc.Add(-2)
+	fmt.Println(tc)
+	// Output:
+	// 00:01:00;02
+	// 00:00:59;28
+}
+
+func ExampleTimecode_AddChecked() {
+	tc, _ := timecode.New("23:59:59:23", 24, false)
+	err := tc.AddChecked(1)
+	fmt.Println(err)
+	// Output: timecode out of range: 23:59:59:23 + 1 frames
+}
+
+func ExampleTimecode_AddDuration() {
+	tc, _ := timecode.New("01:00:00;00", 30, true)
+	tc.AddDuration(10*time.Minute, 30000.0/1001)
+	fmt.Println(tc)
+	// Output: 01:10:00;00
+}
+
+func ExampleDiff() {
+	start, _ := timecode.New("01:00:00:00", 25, false)
+	end, _ := timecode.New("01:00:10:12", 25, false)
+	fmt.Println(timecode.Diff(start, end))
+	// Output: 262
+}
+
+func ExampleTimecode_Seconds() {
+	tc, _ := timecode.New("00:01:00:00", 24, false)
+	fmt.Printf("%.3f\n", tc.Seconds(24000.0/1001))
+	// Output: 60.060
+}
+
+func ExampleTimecode_Format() {
+	tc, _ := timecode.New("13:20:05:10", 25, false)
+	fmt.Println(tc.Format("hh.MM.SS.FF"))
+	// Output: 01.20.05.10
+}
+
+func ExampleValidate() {
+	fmt.Println(timecode.Validate("00:01:00;00", 30, true))
+	fmt.Println(timecode.Validate("00:01:00:25", 25, false))
+	// Output:
+	// <nil>
+	// invalid timecode: 00:01:00:25
+}
+
+func ExampleVerify() {
+	if err := timecode.Verify(30, true); err != nil {
+		panic(err)
+	}
+	fmt.Println("ok")
+	// Output: ok
+}
+
+func ExampleForEachFrame() {
+	start, _ := timecode.New("00:00:59;28", 30, true)
+	timecode.ForEachFrame(start, 4, func(tc *timecode.Timecode) {
+		fmt.Println(tc)
+	})
+	// Output:
+	// 00:00:59;28
+	// 00:00:59;29
+	// 00:01:00;02
+	// 00:01:00;03
+}
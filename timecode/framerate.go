@@ -0,0 +1,128 @@
+package timecode
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FrameRate is a video frame rate with its timecode system.
+type FrameRate struct {
+	// Num and Den are the true rational value of the rate.
+	// ex) 30000/1001 for 29.97 fps
+	Num int
+	Den int
+	// Base is base frame rate for timecode.
+	Base int
+	// Drop is whether the rate uses drop frame timecode.
+	Drop bool
+}
+
+// frameRates is the registry of known frame rates.
+var frameRates = []FrameRate{
+	{24000, 1001, 24, false}, // contrary to our intuition 23.976 isn't a drop frame system.
+	{24, 1, 24, false},
+	{25, 1, 25, false},
+	{30000, 1001, 30, true},
+	{30, 1, 30, false},
+	{48000, 1001, 48, false}, // HFR cinema, as 23.976 it isn't drop frame.
+	{48, 1, 48, false},
+	{50, 1, 50, false},
+	{60000, 1001, 60, true},
+	{60, 1, 60, false},
+}
+
+// Float returns the rate as float.
+func (r FrameRate) Float() float64 {
+	return float64(r.Num) / float64(r.Den)
+}
+
+// String represents the rate as ffprobe does. ex) 29.97, 25
+func (r FrameRate) String() string {
+	if r.Num%r.Den == 0 {
+		return strconv.Itoa(r.Num / r.Den)
+	}
+	return strconv.FormatFloat(r.Float(), 'f', 2, 64)
+}
+
+// rateLookup is a result of lookupFrameRate.
+type rateLookup struct {
+	rate FrameRate
+	ok   bool
+}
+
+// maxCachedRates limits rateCache, as rates that ffprobe reports for broken files
+// could be anything.
+const maxCachedRates = 256
+
+// rateCache memoizes lookupFrameRate by the rational, as every mov looks up its rate.
+// sync.Map doesn't lock for reading, which is almost all of the uses.
+var rateCache sync.Map
+
+// cachedRates is number of the rationals in rateCache.
+var cachedRates int32
+
+// LookupFrameRate finds a known frame rate by its rational representation
+// like 30000/1001 that ffprobe reports as r_frame_rate.
+// It is safe for concurrent use.
+func LookupFrameRate(rational string) (FrameRate, bool) {
+	if l, ok := rateCache.Load(rational); ok {
+		return l.(rateLookup).rate, l.(rateLookup).ok
+	}
+	r, ok := lookupFrameRate(rational)
+	if atomic.LoadInt32(&cachedRates) < maxCachedRates {
+		if _, loaded := rateCache.LoadOrStore(rational, rateLookup{r, ok}); !loaded {
+			atomic.AddInt32(&cachedRates, 1)
+		}
+	}
+	return r, ok
+}
+
+// lookupFrameRate is LookupFrameRate without the cache.
+func lookupFrameRate(rational string) (FrameRate, bool) {
+	num, den, ok := strings.Cut(rational, "/")
+	if !ok {
+		return FrameRate{}, false
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return FrameRate{}, false
+	}
+	d, err := strconv.Atoi(den)
+	if err != nil || d == 0 {
+		return FrameRate{}, false
+	}
+	for _, r := range frameRates {
+		if n*r.Den == r.Num*d {
+			return r, true
+		}
+	}
+	return FrameRate{}, false
+}
+
+// LookupFPS finds a known frame rate by fps that ffprobe shows in its overview.
+// The fps is rounded, so both 23.98 and 23.976 find 24000/1001.
+func LookupFPS(fps string) (FrameRate, bool) {
+	f, err := strconv.ParseFloat(fps, 64)
+	if err != nil {
+		return FrameRate{}, false
+	}
+	for _, r := range frameRates {
+		if math.Abs(f-r.Float()) < 0.01 {
+			return r, true
+		}
+	}
+	return FrameRate{}, false
+}
+
+// KnownBase reports whether a timecode base is used by any known frame rate.
+func KnownBase(base int) bool {
+	for _, r := range frameRates {
+		if r.Base == base {
+			return true
+		}
+	}
+	return false
+}
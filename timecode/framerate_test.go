@@ -0,0 +1,50 @@
+package timecode
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestLookupFrameRateCache(t *testing.T) {
+	rationals := []string{"0/0", "1/0", "24", "abc/1001", "12/1", "48000/1001", "2997/100"}
+	for _, r := range frameRates {
+		rationals = append(rationals, fmt.Sprintf("%d/%d", r.Num, r.Den), fmt.Sprintf("%d/%d", r.Num*2, r.Den*2))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// twice, so the second one is from the cache.
+			for j := 0; j < 2; j++ {
+				for _, rational := range rationals {
+					got, gotOK := LookupFrameRate(rational)
+					want, wantOK := lookupFrameRate(rational)
+					if got != want || gotOK != wantOK {
+						t.Errorf("%v: got %v %v, want %v %v", rational, got, gotOK, want, wantOK)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkLookupFrameRate(b *testing.B) {
+	rationals := []string{"24000/1001", "30000/1001", "25/1", "60000/1001", "12/1"}
+	b.Run("cached", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				LookupFrameRate(rationals[i%len(rationals)])
+			}
+		})
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				lookupFrameRate(rationals[i%len(rationals)])
+			}
+		})
+	})
+}
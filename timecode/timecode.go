@@ -0,0 +1,429 @@
+// Package timecode is SMPTE timecode math in the frame rates movinfo knows.
+// It doesn't probe movs, so it could be used without ffprobe.
+package timecode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidTimecode = errors.New("invalid timecode")
+	ErrUnknownBase     = errors.New("unknown base for timecode")
+	ErrTimecodeRange   = errors.New("timecode out of range")
+)
+
+// Timecode is timecode system that supports bases of the known frame rates,
+// and any other base of non-drop frame timecode with NewNonstandard.
+// See introduction of drop frame timecode system at http://andrewduncan.net/timecodes/
+type Timecode struct {
+	// base is base frame rate for timecode
+	// ex) base frame rate of 29.976 fps is 30.
+	base  int
+	drop  bool
+	frame int
+	// skip is number of frames to reach the next legal frame,
+	// when the timecode was created with a frame number that drop frame system skips.
+	skip int
+}
+
+// New creates new Timecode from code like 01:00:00;00 in the base.
+// Frames could also be after a dot, like 01:00:00.12. Use Format for writing it back.
+// drop is ignored for bases other than 30 and 60, use NewForceDrop for them.
+func New(code string, base int, drop bool) (*Timecode, error) {
+	if base%30 != 0 && drop {
+		// 23.98, 23.978 isn't a drop timecode system, neither 25 or 50.
+		drop = false
+	}
+	return newTimecode(code, base, drop)
+}
+
+// NewRate creates new Timecode in the frame rate.
+func NewRate(code string, rate FrameRate) (*Timecode, error) {
+	return New(code, rate.Base, rate.Drop)
+}
+
+// NewForceDrop creates new drop frame Timecode even for base 24.
+// It drops 2 frames every minute except every tenth minute, as 29.97 does.
+// Note that drop frame 23.976 is non-standard. Use it only for interoperating
+// with files that already have such timecodes.
+func NewForceDrop(code string, base int) (*Timecode, error) {
+	return newTimecode(code, base, true)
+}
+
+// NewNonstandard creates new non-drop frame Timecode in any positive base, like 120
+// of high speed capture that no known frame rate has. Frames have as many digits
+// as the base needs, so it is 00:00:01:119 in base 120.
+// Use New for the known bases, that rejects a base from a wrong rate.
+func NewNonstandard(code string, base int) (*Timecode, error) {
+	if base <= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownBase, base)
+	}
+	return parseTimecode(code, base, false)
+}
+
+// FromFrames creates new Timecode of frames from 00:00:00:00 in the base.
+// frames could be negative, or past 24 hours, that String wraps.
+// Unlike New, drop is used even for base 24, as the frames don't need to be
+// read in a timecode system.
+func FromFrames(frames, base int, drop bool) (*Timecode, error) {
+	if !KnownBase(base) {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownBase, base)
+	}
+	return &Timecode{base: base, drop: drop, frame: frames}, nil
+}
+
+func newTimecode(code string, base int, drop bool) (*Timecode, error) {
+	if !KnownBase(base) {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownBase, base)
+	}
+	return parseTimecode(code, base, drop)
+}
+
+// parseTimecode parses code in the base, that isn't checked.
+func parseTimecode(code string, base int, drop bool) (*Timecode, error) {
+	if strings.HasPrefix(code, "-") {
+		// negative timecode is before zero, ex) pre-roll of a tmcd track.
+		t, err := parseTimecode(code[1:], base, drop)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTimecode, code)
+		}
+		t.frame = -t.frame
+		t.skip = 0
+		return t, nil
+	}
+	fields, ok := splitCode(code, base)
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTimecode, code)
+	}
+	// separators are ':', or ';' of drop frame. frames could also follow '.',
+	// as some European tools write them. (ex. 01:00:00.12)
+	for i := 2; i <= 8; i += 3 {
+		if c := code[i]; c != ':' && c != ';' && (c != '.' || i != 8) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTimecode, code)
+		}
+	}
+	codes := [4]int{}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTimecode, code)
+		}
+		codes[i] = n
+	}
+	h := codes[0]
+	m := codes[1]
+	s := codes[2]
+	f := codes[3]
+	frame := 3600*h*base + 60*m*base + s*base + f
+	skip := 0
+	if drop {
+		n := dropFrames(base)
+		totalMinutes := 60*h + m
+		frame -= n * (totalMinutes - totalMinutes/10)
+		if m%10 != 0 && s == 0 && f < n {
+			// ex) frame 00 and 01 doesn't exist at the start of the minute in base 30.
+			skip = n - f
+		}
+	}
+	t := &Timecode{
+		base:  base,
+		drop:  drop,
+		frame: frame,
+		skip:  skip,
+	}
+	return t, nil
+}
+
+// frameDigits returns number of digits of frames in the base. It is 2 for
+// the known bases, and more only for a nonstandard base over 100.
+func frameDigits(base int) int {
+	if n := len(strconv.Itoa(base - 1)); n > 2 {
+		return n
+	}
+	return 2
+}
+
+// splitCode splits code into hours, minutes, seconds and frames, without the separators.
+// It reports false when the code isn't as long as a timecode of the base.
+func splitCode(code string, base int) ([4]string, bool) {
+	if len(code) != 9+frameDigits(base) {
+		return [4]string{}, false
+	}
+	return [4]string{code[0:2], code[3:5], code[6:8], code[9:]}, true
+}
+
+// dropFrames returns number of frames that drop frame timecode drops every minute.
+// It is 2 for base 30, and 4 for base 60.
+func dropFrames(base int) int {
+	return 2 * ((base + 29) / 30)
+}
+
+// Normalize snaps the Timecode to the nearest legal frame, when it was created
+// with a frame number that drop frame system skips. (ex. 00:01:00;00 to 00:00:59;29,
+// and 00:01:00;01 to 00:01:00;02) It reports whether the Timecode was adjusted.
+func (t *Timecode) Normalize() bool {
+	if t.skip == 0 {
+		return false
+	}
+	// the skipped number is skip numbers before the next legal frame,
+	// and dropFrames - skip + 1 numbers after the previous one.
+	back := 0
+	if 2*t.skip > dropFrames(t.base) {
+		back = 1
+	}
+	t.frame += t.skip - back
+	t.skip = 0
+	return true
+}
+
+// skipForward moves the Timecode to the next legal frame, when it was created
+// with a frame number that drop frame system skips. A clip from a skipped number
+// starts from the frame after it, never the one before it.
+func (t *Timecode) skipForward() {
+	t.frame += t.skip
+	t.skip = 0
+}
+
+// Validate checks the timecode code is valid in the base and drop system.
+// Drop frame timecode should use semicolon before the frame field, and others colon.
+func Validate(code string, base int, drop bool) error {
+	code = strings.TrimPrefix(code, "-")
+	fields, ok := splitCode(code, base)
+	if !ok {
+		return fmt.Errorf("%w: %v", ErrInvalidTimecode, code)
+	}
+	sep := code[8]
+	if drop && sep != ';' {
+		return fmt.Errorf("%w: %v isn't a drop frame timecode", ErrInvalidTimecode, code)
+	}
+	if !drop && sep != ':' {
+		return fmt.Errorf("%w: %v is a drop frame timecode", ErrInvalidTimecode, code)
+	}
+	limits := [4]int{24, 60, 60, base}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 || n >= limits[i] {
+			return fmt.Errorf("%w: %v", ErrInvalidTimecode, code)
+		}
+	}
+	return nil
+}
+
+// Add adds frames to the Timecode. Frames are counted from the frame of
+// a skipped frame number, so the result is a legal frame.
+func (t *Timecode) Add(n int) {
+	t.frame += n
+	if n != 0 {
+		t.skip = 0
+	}
+}
+
+// AddDuration adds real time d to the Timecode, as the nearest number of frames
+// in the true rate. (ex. 30000.0/1001 for 29.97) d could be negative.
+func (t *Timecode) AddDuration(d time.Duration, rate float64) {
+	t.Add(int(math.Round(d.Seconds() * rate)))
+}
+
+// ForEachFrame calls fn with n Timecodes from start in order, one frame apart.
+// The Timecode given to fn is reused for the next frame, so fn should copy it to keep it.
+// start isn't changed.
+func ForEachFrame(start *Timecode, n int, fn func(tc *Timecode)) {
+	tc := *start
+	tc.skipForward()
+	for i := 0; i < n; i++ {
+		fn(&tc)
+		tc.frame++
+	}
+}
+
+// Quarters returns Timecodes of the first frame, the frames at 25%, 50% and 75%,
+// and the last frame of a clip from start that has frames. A position between
+// two frames is the earlier one, so the middle of 100 frames is the 49th from 0.
+// start isn't changed.
+func Quarters(start *Timecode, frames int) [5]*Timecode {
+	if frames < 1 {
+		frames = 1
+	}
+	var q [5]*Timecode
+	for i := range q {
+		tc := *start
+		tc.skipForward()
+		tc.Add((frames - 1) * i / 4)
+		q[i] = &tc
+	}
+	return q
+}
+
+// Midpoint returns Timecode of the middle frame of a clip from start that has frames.
+// It is the one of 50% of Quarters.
+func Midpoint(start *Timecode, frames int) *Timecode {
+	return Quarters(start, frames)[2]
+}
+
+// framesPerDay returns number of frames in 24 hours of the Timecode system.
+func (t *Timecode) framesPerDay() int {
+	n := 24 * 60 * 60 * t.base
+	if t.drop {
+		// every minute except every tenth minute drops frames.
+		n -= dropFrames(t.base) * (24*60 - 24*6)
+	}
+	return n
+}
+
+// PastMidnight reports whether the Timecode is 24 hours or more from 00:00:00:00,
+// that String wraps to the next day.
+func (t *Timecode) PastMidnight() bool {
+	return t.frame >= t.framesPerDay()
+}
+
+// AddChecked adds frames to the Timecode like Add, but returns an error
+// instead when the result would be negative or past 24 hours.
+func (t *Timecode) AddChecked(n int) error {
+	frame := t.frame + n
+	if frame < 0 || frame >= t.framesPerDay() {
+		return fmt.Errorf("%w: %v + %v frames", ErrTimecodeRange, t, n)
+	}
+	t.frame = frame
+	return nil
+}
+
+// Diff returns number of frames from a to b. It is negative when b is before a.
+// Both should be in the same timecode system.
+func Diff(a, b *Timecode) int {
+	return b.frame - a.frame
+}
+
+// Frames returns number of frames from 00:00:00:00 to the Timecode.
+// It is negative for a negative Timecode.
+func (t *Timecode) Frames() int {
+	return t.frame
+}
+
+// Base returns base frame rate of the Timecode. (ex. 30 for 29.97)
+func (t *Timecode) Base() int {
+	return t.base
+}
+
+// Drop reports whether the Timecode is in drop frame system.
+func (t *Timecode) Drop() bool {
+	return t.drop
+}
+
+// Seconds returns seconds from 00:00:00:00 to the Timecode in the rate.
+// The rate should be the real frame rate (ex. 29.97), not the base.
+func (t *Timecode) Seconds(rate float64) float64 {
+	return float64(t.frame) / rate
+}
+
+// clock returns hours, minutes, seconds and frames of the Timecode.
+//
+// Drop frame timecode skips the first n frame numbers of every minute, except every
+// tenth minute. Frames are converted back to the numbers by adding the skipped ones:
+//
+//   - a minute that drops has 60*base - n frames; 1798 for base 30.
+//   - 10 minutes have 600*base - 9*n frames, as the first minute doesn't drop; 17982 for base 30.
+//   - each whole 10 minutes before the frame skipped 9*n numbers.
+//   - in the last 10 minutes, the first minute has 60*base frames, and the others
+//     have 60*base - n frames. So the first n frames are in the first minute,
+//     and from there every 60*base - n frames is a minute that skipped n numbers.
+//
+// A frame exactly on a 10 minutes boundary is the first frame of the first minute,
+// that keeps its numbers. (ex. frame 17982 is 00:10:00;00)
+func (t *Timecode) clock() (h, m, s, f int) {
+	base := t.base
+	frame := t.frame
+	if t.drop {
+		n := dropFrames(base)
+		minute := 60*base - n
+		tenMinutes := 600*base - 9*n
+		tens := frame / tenMinutes
+		rest := frame % tenMinutes
+		frame += 9 * n * tens
+		if rest >= n {
+			// minutes after the first one in the 10 minutes. rest < n is in
+			// the first minute, which (rest-n)/minute also gives in Go,
+			// but only as it truncates toward zero.
+			frame += n * ((rest - n) / minute)
+		}
+	}
+	h = frame / base / 60 / 60 % 24
+	m = frame / base / 60 % 60
+	s = frame / base % 60
+	f = frame % base
+	return h, m, s, f
+}
+
+// String represents the Timecode as string.
+// Negative Timecode has minus sign in front of it. (ex. -00:00:01:00)
+func (t *Timecode) String() string {
+	if t.frame < 0 {
+		neg := *t
+		neg.frame = -t.frame
+		return "-" + neg.String()
+	}
+	h, m, s, f := t.clock()
+	codes := [4]int{h, m, s, f}
+	timecode := ""
+	for i, c := range codes {
+		if i == 1 || i == 2 {
+			timecode += ":"
+		}
+		if i == 3 {
+			if t.drop {
+				timecode += ";"
+			} else {
+				timecode += ":"
+			}
+		}
+		tc := strconv.Itoa(c)
+		if len(tc) == 1 {
+			tc = "0" + tc
+		}
+		if i == 3 {
+			for len(tc) < frameDigits(t.base) {
+				tc = "0" + tc
+			}
+		}
+		timecode += tc
+	}
+	return timecode
+}
+
+// Format represents the Timecode as string in the layout.
+// HH, MM, SS and FF in the layout are replaced with hours, minutes, seconds and frames,
+// and hh with hours in 12-hour clock. Other characters are kept as is,
+// so separators are up to the layout. (ex. "HH:MM:SS.FF", "HH:MM:SS;FF")
+func (t *Timecode) Format(layout string) string {
+	if t.frame < 0 {
+		neg := *t
+		neg.frame = -t.frame
+		return "-" + neg.Format(layout)
+	}
+	h, m, s, f := t.clock()
+	h12 := h % 12
+	if h12 == 0 {
+		h12 = 12
+	}
+	tokens := map[string]int{"HH": h, "hh": h12, "MM": m, "SS": s, "FF": f}
+	out := ""
+	for i := 0; i < len(layout); i++ {
+		if i+1 < len(layout) {
+			if n, ok := tokens[layout[i:i+2]]; ok {
+				width := 2
+				if layout[i:i+2] == "FF" {
+					width = frameDigits(t.base)
+				}
+				out += fmt.Sprintf("%0*d", width, n)
+				i++
+				continue
+			}
+		}
+		out += layout[i : i+1]
+	}
+	return out
+}
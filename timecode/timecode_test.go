@@ -0,0 +1,213 @@
+package timecode
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestForEachFrame(t *testing.T) {
+	start, err := New("00:09:59;27", 30, true)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	// over the tenth minute, that doesn't drop, and the next one that does.
+	want := []string{"00:09:59;27", "00:09:59;28", "00:09:59;29", "00:10:00;00", "00:10:00;01"}
+	got := []string{}
+	ForEachFrame(start, len(want), func(tc *Timecode) {
+		got = append(got, tc.String())
+	})
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	start, err = New("00:10:59;28", 30, true)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	want = []string{"00:10:59;28", "00:10:59;29", "00:11:00;02", "00:11:00;03"}
+	got = got[:0]
+	ForEachFrame(start, len(want), func(tc *Timecode) {
+		got = append(got, tc.String())
+	})
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if start.String() != "00:10:59;28" {
+		t.Fatalf("start is changed to %v", start)
+	}
+	// a skipped frame starts from the next legal frame.
+	skipped, err := NewForceDrop("00:01:00;00", 30)
+	if err != nil {
+		t.Fatalf("NewForceDrop error: %v", err)
+	}
+	ForEachFrame(skipped, 1, func(tc *Timecode) {
+		if tc.String() != "00:01:00;02" {
+			t.Fatalf("got %v, want 00:01:00;02", tc)
+		}
+	})
+	frames := 0
+	allocs := testing.AllocsPerRun(10, func() {
+		ForEachFrame(start, 10000, func(tc *Timecode) {
+			frames += tc.Frames() & 1
+		})
+	})
+	if allocs > 1 {
+		t.Fatalf("got %v allocations, want at most 1", allocs)
+	}
+}
+
+func TestNewSeparators(t *testing.T) {
+	cases := []struct {
+		code string
+		drop bool
+		want string
+		err  bool
+	}{
+		{"01:00:00:12", false, "01:00:00:12", false},
+		{"01:00:00;12", true, "01:00:00;12", false},
+		{"01:00:00.12", false, "01:00:00:12", false},
+		{"01:00:00.12", true, "01:00:00;12", false},
+		{"01:01:00.00", true, "01:00:59;29", false},
+		{"01.00.00.12", false, "", true},
+		{"01:00:00,12", false, "", true},
+		{"01:00:00 12", false, "", true},
+	}
+	for _, c := range cases {
+		tc, err := New(c.code, 30, c.drop)
+		if c.err {
+			if err == nil {
+				t.Fatalf("%v: want error", c.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v: New error: %v", c.code, err)
+		}
+		tc.Normalize()
+		if got := tc.String(); got != c.want {
+			t.Fatalf("%v: got %v, want %v", c.code, got, c.want)
+		}
+	}
+}
+
+func TestNewNonstandard(t *testing.T) {
+	tc, err := NewNonstandard("00:00:01:119", 120)
+	if err != nil {
+		t.Fatalf("NewNonstandard error: %v", err)
+	}
+	if tc.Frames() != 239 || tc.String() != "00:00:01:119" || tc.Drop() {
+		t.Fatalf("got %v %v drop %v, want 00:00:01:119 239 drop false", tc, tc.Frames(), tc.Drop())
+	}
+	tc.Add(1)
+	if got := tc.String(); got != "00:00:02:000" {
+		t.Fatalf("got %v, want 00:00:02:000", got)
+	}
+	// an hour is 3600 * 120 frames.
+	tc, err = NewNonstandard("01:00:00:000", 120)
+	if err != nil {
+		t.Fatalf("NewNonstandard error: %v", err)
+	}
+	if tc.Frames() != 432000 || tc.Seconds(120) != 3600 {
+		t.Fatalf("got %v frames %v seconds, want 432000 frames 3600 seconds", tc.Frames(), tc.Seconds(120))
+	}
+	tc.Add(-1)
+	if got := tc.Format("HH:MM:SS.FF"); got != "00:59:59.119" {
+		t.Fatalf("got %v, want 00:59:59.119", got)
+	}
+	if err := Validate("00:59:59:119", 120, false); err != nil {
+		t.Fatalf("Validate error: %v", err)
+	}
+	if err := Validate("00:59:59:120", 120, false); !errors.Is(err, ErrInvalidTimecode) {
+		t.Fatalf("got error %v, want %v", err, ErrInvalidTimecode)
+	}
+	neg, err := NewNonstandard("-00:00:00:005", 120)
+	if err != nil || neg.Frames() != -5 || neg.String() != "-00:00:00:005" {
+		t.Fatalf("got %v %v, %v, want -00:00:00:005 -5", neg, neg.Frames(), err)
+	}
+	// base 100 still has 2 digits of frames.
+	tc, err = NewNonstandard("00:00:00:99", 100)
+	if err != nil || tc.String() != "00:00:00:99" {
+		t.Fatalf("got %v, %v, want 00:00:00:99", tc, err)
+	}
+	for _, c := range []struct {
+		code string
+		base int
+		err  error
+	}{
+		{"00:00:01:19", 120, ErrInvalidTimecode},
+		{"00:00:01;119", 120, nil},
+		{"00:00:01:00", 0, ErrUnknownBase},
+		{"00:00:01:00", -24, ErrUnknownBase},
+	} {
+		if _, err := NewNonstandard(c.code, c.base); !errors.Is(err, c.err) {
+			t.Fatalf("%v in %v: got error %v, want %v", c.code, c.base, err, c.err)
+		}
+	}
+	// the default constructors keep rejecting a base no known rate has.
+	if _, err := New("00:00:01:119", 120, false); !errors.Is(err, ErrUnknownBase) {
+		t.Fatalf("got error %v, want %v", err, ErrUnknownBase)
+	}
+}
+
+func TestQuarters(t *testing.T) {
+	cases := []struct {
+		start  string
+		frames int
+		want   [5]string
+	}{
+		// 450 frames after 00:00:45;00 is 15 seconds in the labels, 00:01:00;00,
+		// that is skipped. so it is 00:01:00;02, and the later ones are 2 frames later too.
+		{"00:00:45;00", 1801, [5]string{"00:00:45;00", "00:01:00;02", "00:01:15;02", "00:01:30;02", "00:01:45;02"}},
+		// a frame less, the 25% is 449 frames after the start, the last one before the minute.
+		{"00:00:45;00", 1800, [5]string{"00:00:45;00", "00:00:59;29", "00:01:15;01", "00:01:30;01", "00:01:45;01"}},
+		// the start is a skipped frame, that moves to the next legal frame.
+		{"00:01:00;00", 5, [5]string{"00:01:00;02", "00:01:00;03", "00:01:00;04", "00:01:00;05", "00:01:00;06"}},
+		{"10:00:00;00", 1, [5]string{"10:00:00;00", "10:00:00;00", "10:00:00;00", "10:00:00;00", "10:00:00;00"}},
+	}
+	for _, c := range cases {
+		start, err := New(c.start, 30, true)
+		if err != nil {
+			t.Fatalf("New error: %v", err)
+		}
+		before := *start
+		q := Quarters(start, c.frames)
+		for i := range q {
+			if q[i].String() != c.want[i] {
+				t.Fatalf("%v %v frames: got %v at %v, want %v", c.start, c.frames, q[i], i, c.want[i])
+			}
+		}
+		if *start != before {
+			t.Fatalf("start is changed to %+v from %+v", *start, before)
+		}
+		if got := Midpoint(start, c.frames); got.String() != c.want[2] {
+			t.Fatalf("%v %v frames: got midpoint %v, want %v", c.start, c.frames, got, c.want[2])
+		}
+	}
+}
+
+func TestPastMidnight(t *testing.T) {
+	cases := []struct {
+		code string
+		drop bool
+		add  int
+		want bool
+	}{
+		{"23:59:59;29", true, 0, false},
+		{"23:59:59;29", true, 1, true},
+		{"23:59:59:29", false, 1, true},
+		{"00:00:00:00", false, -1, false},
+	}
+	for _, c := range cases {
+		tc, err := New(c.code, 30, c.drop)
+		if err != nil {
+			t.Fatalf("New(%v): %v", c.code, err)
+		}
+		tc.Add(c.add)
+		if got := tc.PastMidnight(); got != c.want {
+			t.Fatalf("%v + %v: got %v, want %v", c.code, c.add, got, c.want)
+		}
+	}
+}
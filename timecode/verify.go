@@ -0,0 +1,74 @@
+package timecode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDrift is the error of Verify, when frames and strings of the timecode
+// system don't convert back to themselves.
+var ErrDrift = errors.New("timecode drift")
+
+// Verify checks frames and strings of the timecode system convert back to
+// themselves at every minute boundary of 24 hours. The frames before,
+// at and after every boundary are checked, and the number of frames between
+// the boundaries should be what the system has in a minute.
+// It is a self-check for the drop frame math, which is easy to get off by one
+// near every tenth minute. drop is used even for base 24, as NewForceDrop does.
+func Verify(base int, drop bool) error {
+	if !KnownBase(base) {
+		return fmt.Errorf("%w: %v", ErrUnknownBase, base)
+	}
+	n := 0
+	if drop {
+		n = dropFrames(base)
+	}
+	sep := ":"
+	if drop {
+		sep = ";"
+	}
+	prev := -1
+	prevFirst := 0
+	for minutes := 0; minutes < 24*60; minutes++ {
+		h, m := minutes/60, minutes%60
+		// the first frame that exists in the minute.
+		first := 0
+		if m%10 != 0 {
+			first = n
+		}
+		code := fmt.Sprintf("%02d:%02d:00%v%02d", h, m, sep, first)
+		tc, err := newTimecode(code, base, drop)
+		if err != nil {
+			return err
+		}
+		if tc.skip != 0 {
+			return fmt.Errorf("%w: %v is taken as a skipped frame", ErrDrift, code)
+		}
+		if got := tc.String(); got != code {
+			return fmt.Errorf("%w: %v is frame %v, which is %v", ErrDrift, code, tc.frame, got)
+		}
+		if prev != -1 {
+			// the previous minute has all the frames except the ones it dropped.
+			if got, want := tc.frame-prev, 60*base-prevFirst; got != want {
+				return fmt.Errorf("%w: %v frames before %v, want %v", ErrDrift, got, code, want)
+			}
+		}
+		prev = tc.frame
+		prevFirst = first
+		for _, frame := range []int{tc.frame - 1, tc.frame, tc.frame + 1} {
+			if frame < 0 {
+				continue
+			}
+			t := &Timecode{base: base, drop: drop, frame: frame}
+			s := t.String()
+			back, err := newTimecode(s, base, drop)
+			if err != nil {
+				return fmt.Errorf("%w: frame %v is %v: %v", ErrDrift, frame, s, err)
+			}
+			if back.frame != frame || back.skip != 0 {
+				return fmt.Errorf("%w: frame %v is %v, which is frame %v", ErrDrift, frame, s, back.frame)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package timecode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	for _, r := range frameRates {
+		for _, drop := range []bool{false, true} {
+			if err := Verify(r.Base, drop); err != nil {
+				t.Fatalf("%v drop %v: %v", r, drop, err)
+			}
+		}
+	}
+	if err := Verify(23, false); !errors.Is(err, ErrUnknownBase) {
+		t.Fatalf("got %v, want %v", err, ErrUnknownBase)
+	}
+}
+
+// TestDropFrameDay round-trips every frame of 24 hours of drop frame timecode,
+// not only the minute boundaries that Verify checks.
+func TestDropFrameDay(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping every frame of a day in short mode")
+	}
+	for _, base := range []int{30, 60} {
+		tc := &Timecode{base: base, drop: true}
+		days := tc.framesPerDay()
+		for frame := 0; frame < days; frame++ {
+			tc.frame = frame
+			s := tc.String()
+			back, err := New(s, base, true)
+			if err != nil {
+				t.Fatalf("base %v frame %v is %v: %v", base, frame, s, err)
+			}
+			if back.frame != frame || back.skip != 0 {
+				t.Fatalf("base %v frame %v is %v, which is frame %v", base, frame, s, back.frame)
+			}
+		}
+		// the last frame of the day.
+		tc.frame = days - 1
+		want := "23:59:59;29"
+		if base == 60 {
+			want = "23:59:59;59"
+		}
+		if got := tc.String(); got != want {
+			t.Fatalf("base %v: got %v, want %v", base, got, want)
+		}
+	}
+}
+
+func TestDropFrameTenMinutes(t *testing.T) {
+	// frames around the tenth minute, where 17982 and 1798 meet.
+	cases := []struct {
+		frame int
+		want  string
+	}{
+		{1799, "00:00:59;29"},
+		{1800, "00:01:00;02"},
+		{17981, "00:09:59;29"},
+		{17982, "00:10:00;00"},
+		{17983, "00:10:00;01"},
+		{17982 + 1800, "00:11:00;02"},
+		{6*17982 - 1, "00:59:59;29"},
+		{6 * 17982, "01:00:00;00"},
+	}
+	for _, c := range cases {
+		tc := &Timecode{base: 30, drop: true, frame: c.frame}
+		if got := tc.String(); got != c.want {
+			t.Fatalf("frame %v: got %v, want %v", c.frame, got, c.want)
+		}
+	}
+}
+
+// TestDropFrameHour compares every frame of an hour with a counter that ticks
+// timecode numbers like a clock and skips the dropped ones, so every 1 minute
+// and 10 minutes boundary is checked without the math of clock.
+func TestDropFrameHour(t *testing.T) {
+	for _, base := range []int{24, 30, 60} {
+		n := dropFrames(base)
+		h, m, s, f := 0, 0, 0, 0
+		for frame := 0; frame < 3600*base; frame++ {
+			tc := &Timecode{base: base, drop: true, frame: frame}
+			want := fmt.Sprintf("%02d:%02d:%02d;%02d", h, m, s, f)
+			if got := tc.String(); got != want {
+				t.Fatalf("base %v frame %v: got %v, want %v", base, frame, got, want)
+			}
+			f++
+			if f == base {
+				f = 0
+				s++
+			}
+			if s == 60 {
+				s = 0
+				m++
+				if m%10 != 0 {
+					f = n
+				}
+			}
+			if m == 60 {
+				m = 0
+				h++
+			}
+		}
+		// an hour has 6 of 10 minutes.
+		tc := &Timecode{base: base, drop: true, frame: 6 * (600*base - 9*n)}
+		if got, want := tc.String(), "01:00:00;00"; got != want {
+			t.Fatalf("base %v: got %v, want %v", base, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package movinfo
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// tmcdArgs are ffprobe arguments to dump the first sample of the tmcd track
+// at the stream index. The sample is the frame number of the start timecode,
+// as a 32-bit big-endian signed integer.
+func tmcdArgs(index int) []string {
+	return []string{"-select_streams", strconv.Itoa(index), "-show_packets", "-show_data", "-read_intervals", "%+#1"}
+}
+
+// parseTmcdFrame parses the frame number from ffprobe output of tmcdArgs.
+// The data is a hexdump like
+//
+//	data=
+//	00000000: 0001 5180                                ..Q.
+func parseTmcdFrame(data string) (int, error) {
+	_, dump, ok := strings.Cut(data, "data=\n")
+	if !ok {
+		return 0, fmt.Errorf("no tmcd sample")
+	}
+	line, _, _ := strings.Cut(dump, "\n")
+	_, line, ok = strings.Cut(line, ": ")
+	if !ok {
+		return 0, fmt.Errorf("invalid tmcd sample: %v", line)
+	}
+	// hex is followed by two spaces and ascii of it.
+	line, _, _ = strings.Cut(line, "  ")
+	b, err := hex.DecodeString(strings.ReplaceAll(line, " ", ""))
+	if err != nil || len(b) < 4 {
+		return 0, fmt.Errorf("invalid tmcd sample: %v", line)
+	}
+	return int(int32(binary.BigEndian.Uint32(b))), nil
+}
+
+// Rates that count start and end of -end-rate.
+const (
+	endRateTimecode = "timecode"
+	endRateVideo    = "video"
+)
+
+// checkTimecodeRate checks the tmcd track counts frames in the rate of the video,
+// that is r_frame_rate, or fps when it isn't available. Timecode of a fraction
+// of the rate, like 29.97 of 59.94 video, is common and isn't a problem.
+// It returns the problem, or "" when there isn't.
+func checkTimecodeRate(tmcd tmcdInfo, videoRate, fps string) string {
+	vr, err := parseRate(videoRate)
+	if err != nil {
+		vr, err = strconv.ParseFloat(fps, 64)
+	}
+	if err != nil || vr <= 0 || tmcd.rate <= 0 {
+		return ""
+	}
+	base := int(math.Round(tmcd.rate))
+	vbase := int(math.Round(vr))
+	if base == 0 || vbase%base == 0 {
+		return ""
+	}
+	return fmt.Sprintf("timecode track is %v fps, but the video is %v fps", formatFPS(tmcd.rate), formatFPS(vr))
+}
+
+// formatFPS formats a frame rate like fps of ffprobe's overview. (ex. 23.98, 30)
+func formatFPS(r float64) string {
+	if r == math.Trunc(r) {
+		return strconv.Itoa(int(r))
+	}
+	return strconv.FormatFloat(r, 'f', 2, 64)
+}
+
+// tmcdStart returns start timecode of the frame number of the tmcd track.
+// ffprobe only tells drop frame with the timecode tag, so without it the frame
+// number is drop frame when the rate of the track is, like 29.97 and 59.94.
+func tmcdStart(tmcd tmcdInfo, frames int) (string, error) {
+	base := int(math.Round(tmcd.rate))
+	drop := tmcd.drop
+	if !tmcd.tagged {
+		if rate, ok := lookupFPS(strconv.FormatFloat(tmcd.rate, 'f', -1, 64)); ok {
+			drop = rate.Drop
+		}
+	}
+	tc, err := NewTimecodeFromFrames(frames, base, drop)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v (tmcd)", ErrUnsupportedFPS, tmcd.rate)
+	}
+	return tc.String(), nil
+}
+
+// probeTmcdStart reads the frame number of the tmcd track in the ffprobe output
+// of -show_streams, and returns it as start timecode.
+// It is for movs that don't have the timecode tag, or have one that is out of range.
+func probeTmcdStart(ctx context.Context, cmd []string, file string, cfg config, data string) (string, error) {
+	tmcd := findTmcd(strings.SplitAfter(data, "[/STREAM]"))
+	if tmcd.index == -1 {
+		return "", ErrMissingTimecode
+	}
+	out, err := probe(ctx, cmd, file, tmcdArgs(tmcd.index)...)
+	if err != nil {
+		return "", err
+	}
+	frames, err := parseTmcdFrame(out)
+	if err != nil {
+		return "", err
+	}
+	return tmcdStart(tmcd, frames)
+}
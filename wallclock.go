@@ -0,0 +1,41 @@
+package movinfo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseWallClock parses a time of -frame-at to seconds. It is seconds, or
+// minutes and seconds, or hours, minutes and seconds separated by colon.
+// (ex. 90.5, 01:30.5, 00:01:30.5)
+func parseWallClock(s string) (float64, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time, need [[HH:]MM:]SS[.sss]: %v", s)
+	}
+	sec := 0.0
+	for i, p := range parts {
+		last := i == len(parts)-1
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil || v < 0 || math.IsInf(v, 0) || (!last && v != math.Trunc(v)) || (i > 0 && v >= 60) {
+			return 0, fmt.Errorf("invalid time, need [[HH:]MM:]SS[.sss]: %v", s)
+		}
+		sec = sec*60 + v
+	}
+	return sec, nil
+}
+
+// frameAt returns index of the frame nearest to sec seconds from the start of the clip,
+// that starts from start and has frames in the real rate, and its timecode.
+// It fails when the frame is after the last frame of the clip.
+func frameAt(start *Timecode, frames int, sec, rate float64) (int, *Timecode, error) {
+	n := int(math.Round(sec * rate))
+	if n >= frames {
+		return 0, nil, fmt.Errorf("%w: %v seconds is after the end of the mov, that is %v seconds long", ErrTimecodeRange, formatSeconds(sec), formatSeconds(float64(frames)/rate))
+	}
+	tc := *start
+	tc.Add(n)
+	return n, &tc, nil
+}
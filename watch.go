@@ -0,0 +1,151 @@
+package movinfo
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchSettle is number of polls a new file should stay the same size,
+// before -watch takes it as completely written.
+const watchSettle = 2
+
+// mediaExts are extensions of the files -watch probes. Others, like partial
+// downloads and sidecar files, are ignored.
+var mediaExts = map[string]bool{
+	"mov":  true,
+	"mp4":  true,
+	"m4v":  true,
+	"mxf":  true,
+	"mkv":  true,
+	"avi":  true,
+	"mts":  true,
+	"m2ts": true,
+	"ts":   true,
+	"webm": true,
+}
+
+// fileState is size and modification time of a file at a poll.
+type fileState struct {
+	size    int64
+	modTime int64
+}
+
+// pendingFile is a new file that might be still being written.
+type pendingFile struct {
+	state fileState
+	// stable is number of polls the state stayed the same.
+	stable int
+}
+
+// watcher finds new files in a directory, that are completely written.
+type watcher struct {
+	settle int
+	// seen is the files already found, or there before watching.
+	seen    map[string]bool
+	pending map[string]*pendingFile
+}
+
+// newWatcher creates a watcher that ignores the existing files.
+func newWatcher(existing map[string]fileState, settle int) *watcher {
+	w := &watcher{
+		settle:  settle,
+		seen:    map[string]bool{},
+		pending: map[string]*pendingFile{},
+	}
+	for name := range existing {
+		w.seen[name] = true
+	}
+	return w
+}
+
+// update takes states of the files in the directory at a poll, and returns
+// the new files that didn't change for settle polls, sorted by name.
+// Empty files aren't ready, as they are usually just created.
+func (w *watcher) update(states map[string]fileState) []string {
+	ready := []string{}
+	for name, st := range states {
+		if w.seen[name] {
+			continue
+		}
+		p, ok := w.pending[name]
+		if !ok || p.state != st {
+			w.pending[name] = &pendingFile{state: st}
+			continue
+		}
+		if st.size == 0 {
+			continue
+		}
+		p.stable++
+		if p.stable >= w.settle {
+			delete(w.pending, name)
+			w.seen[name] = true
+			ready = append(ready, name)
+		}
+	}
+	for name := range w.pending {
+		if _, ok := states[name]; !ok {
+			// removed while being written.
+			delete(w.pending, name)
+		}
+	}
+	sort.Strings(ready)
+	return ready
+}
+
+// scanDir returns states of the media files in the directory.
+// Hidden files are skipped, as they are often temporary files of a copy.
+func scanDir(dir string) (map[string]fileState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]fileState{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if !mediaExts[strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))] {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			// removed after ReadDir.
+			continue
+		}
+		states[name] = fileState{size: info.Size(), modTime: info.ModTime().UnixNano()}
+	}
+	return states, nil
+}
+
+// watchDir polls the directory every interval, and calls found with path of each new
+// media file after it is completely written. It returns only when the directory cannot be read at first,
+// or interval isn't positive.
+func watchDir(dir string, interval time.Duration, found func(file string)) error {
+	if interval <= 0 {
+		// time.Tick would never tick.
+		return fmt.Errorf("invalid interval: %v", interval)
+	}
+	existing, err := scanDir(dir)
+	if err != nil {
+		return err
+	}
+	w := newWatcher(existing, watchSettle)
+	for range time.Tick(interval) {
+		states, err := scanDir(dir)
+		if err != nil {
+			// the directory could be on a network storage, try again at the next poll.
+			log.Print(err)
+			continue
+		}
+		for _, name := range w.update(states) {
+			found(filepath.Join(dir, name))
+		}
+	}
+	return nil
+}